@@ -0,0 +1,296 @@
+package docweaver
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Admonition is a callout block, written either as a GitHub style alert (`> [!NOTE]`) or as a fenced container
+// (`:::note Title` ... `:::`).
+type Admonition struct {
+	ast.BaseBlock
+	AdmonitionType string // one of note, tip, warning, danger, info
+	Title          string
+	Collapsible    bool
+	Open           bool // whether a collapsible admonition is expanded initially
+}
+
+// KindAdmonition is the ast.NodeKind of Admonition nodes.
+var KindAdmonition = ast.NewNodeKind("Admonition")
+
+// admonitions renders admonitions.
+type admonitions struct{}
+
+type admonitionParser struct{}
+
+type alertTransformer struct{}
+
+type containerFence struct {
+	length int
+	name   string
+	rest   string
+}
+
+const containerFenceChar = ':'
+
+// admonitionTypes maps admonition type names, including aliases, to their type.
+var admonitionTypes = map[string]string{
+	"note":      "note",
+	"tip":       "tip",
+	"hint":      "tip",
+	"warning":   "warning",
+	"danger":    "danger",
+	"caution":   "danger",
+	"info":      "info",
+	"important": "info",
+}
+
+var alertMarkerPattern = regexp.MustCompile(`^\[!(\w+)\]([+-]?)[ \t]*(.*)$`)
+
+// containerFencesKey is the parser context key of the fence lengths of open containers.
+var containerFencesKey = parser.NewContextKey()
+
+func (n *Admonition) Kind() ast.NodeKind {
+	return KindAdmonition
+}
+
+func (n *Admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"AdmonitionType": n.AdmonitionType,
+		"Title":          n.Title,
+	}, nil)
+}
+
+// newAdmonition returns an Admonition of the type named by name, or nil if it does not name an admonition type.
+// A trailing "-" marks a collapsed and a trailing "+" an expanded collapsible admonition.
+func newAdmonition(name, collapse, title string) *Admonition {
+	admonitionType, ok := admonitionTypes[strings.ToLower(name)]
+	if !ok {
+		return nil
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = strings.ToUpper(admonitionType[:1]) + admonitionType[1:]
+	}
+
+	return &Admonition{
+		AdmonitionType: admonitionType,
+		Title:          title,
+		Collapsible:    collapse != "",
+		Open:           collapse == "+",
+	}
+}
+
+// parseContainerFence parses a container fence line such as ":::warning- Title". Fences consist of at least three
+// colons; containers are nested by using longer fences for outer containers.
+func parseContainerFence(line []byte, offset int) (fence containerFence, ok bool) {
+	w, pos := util.IndentWidth(line, offset)
+	if w > 3 {
+		return fence, false
+	}
+
+	i := pos
+	for ; i < len(line) && line[i] == containerFenceChar; i++ {
+	}
+	fence.length = i - pos
+	if fence.length < 3 {
+		return fence, false
+	}
+
+	rest := strings.TrimSpace(string(line[i:]))
+	fence.name = rest
+	if idx := strings.IndexAny(rest, " \t"); idx >= 0 {
+		fence.name, fence.rest = rest[:idx], strings.TrimSpace(rest[idx:])
+	}
+
+	return fence, true
+}
+
+// openContainer records the fence length of a container node being opened.
+func openContainer(pc parser.Context, node ast.Node, length int) {
+	fences, _ := pc.Get(containerFencesKey).(map[ast.Node]int)
+	if fences == nil {
+		fences = map[ast.Node]int{}
+		pc.Set(containerFencesKey, fences)
+	}
+	fences[node] = length
+}
+
+// continueContainer advances past the closing fence of a container node and returns parser.Close if the current line
+// closes it. Otherwise the line is left to the container's children.
+func continueContainer(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	fences, _ := pc.Get(containerFencesKey).(map[ast.Node]int)
+	if isContainerFenceEnd(line, reader.LineOffset(), fences[node]) {
+		advanceLine(reader, line, segment)
+		return parser.Close
+	}
+
+	return parser.Continue | parser.HasChildren
+}
+
+// advanceLine advances the reader past the content of the given line, the reader's current one, leaving its newline
+// (if any, the last line of a file may lack one) to the parser.
+func advanceLine(reader text.Reader, line []byte, segment text.Segment) {
+	n := segment.Len()
+	if n > 0 && line[len(line)-1] == '\n' {
+		n--
+	}
+	reader.Advance(n)
+}
+
+// closeContainer forgets the fence length of a closed container node.
+func closeContainer(node ast.Node, pc parser.Context) {
+	if fences, _ := pc.Get(containerFencesKey).(map[ast.Node]int); fences != nil {
+		delete(fences, node)
+	}
+}
+
+// isContainerFenceEnd reports whether line closes a container opened with a fence of the given length.
+func isContainerFenceEnd(line []byte, offset, length int) bool {
+	fence, ok := parseContainerFence(line, offset)
+	return ok && fence.name == "" && fence.length >= length
+}
+
+func (a *admonitions) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&admonitionParser{}, 150)),
+		parser.WithASTTransformers(util.Prioritized(&alertTransformer{}, 100)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(a, 100)))
+}
+
+func (a *admonitions) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindAdmonition, a.renderAdmonition)
+}
+
+func (a *admonitions) renderAdmonition(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*Admonition)
+	tag, titleTag := "aside", "p"
+	if n.Collapsible {
+		tag, titleTag = "details", "summary"
+	}
+
+	if !entering {
+		_, _ = fmt.Fprintf(w, "</%s>\n", tag)
+		return ast.WalkContinue, nil
+	}
+
+	open := ""
+	if n.Open {
+		open = " open"
+	}
+	_, _ = fmt.Fprintf(w, "<%s class=\"admonition admonition-%s\"%s>\n", tag, n.AdmonitionType, open)
+	_, _ = fmt.Fprintf(w, "<%s class=\"admonition-title\">%s</%s>\n", titleTag, html.EscapeString(n.Title), titleTag)
+
+	return ast.WalkContinue, nil
+}
+
+func (p *admonitionParser) Trigger() []byte {
+	return []byte{containerFenceChar}
+}
+
+func (p *admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	fence, ok := parseContainerFence(line, reader.LineOffset())
+	if !ok {
+		return nil, parser.NoChildren
+	}
+
+	name, collapse := fence.name, ""
+	if strings.HasSuffix(name, "-") || strings.HasSuffix(name, "+") {
+		name, collapse = name[:len(name)-1], name[len(name)-1:]
+	}
+	node := newAdmonition(name, collapse, fence.rest)
+	if node == nil {
+		return nil, parser.NoChildren
+	}
+
+	openContainer(pc, node, fence.length)
+	advanceLine(reader, line, segment)
+
+	return node, parser.HasChildren
+}
+
+func (p *admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return continueContainer(node, reader, pc)
+}
+
+func (p *admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	closeContainer(node, pc)
+}
+
+func (p *admonitionParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *admonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// Transform replaces blockquotes starting with an alert marker (`[!NOTE]`) by admonitions. Text following the marker
+// on the same line is used as the title.
+func (t *alertTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var quotes []*ast.Blockquote
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if q, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, q)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, q := range quotes {
+		para, ok := q.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+
+		first := para.Lines().At(0)
+		m := alertMarkerPattern.FindSubmatch(bytes.TrimSpace(first.Value(source)))
+		if m == nil {
+			continue
+		}
+		node := newAdmonition(string(m[1]), string(m[2]), string(m[3]))
+		if node == nil {
+			continue
+		}
+
+		removeFirstLine(para, first.Start+len(bytes.TrimRight(first.Value(source), " \t\r\n")))
+		if para.ChildCount() == 0 {
+			q.RemoveChild(q, para)
+		}
+		for c := q.FirstChild(); c != nil; c = q.FirstChild() {
+			node.AppendChild(node, c)
+		}
+		q.Parent().ReplaceChild(q.Parent(), q, node)
+	}
+}
+
+// removeFirstLine removes the inline nodes of the first line of a paragraph, which ends at lineStop.
+func removeFirstLine(para *ast.Paragraph, lineStop int) {
+	for c := para.FirstChild(); c != nil; {
+		next := c.NextSibling()
+		t, isText := c.(*ast.Text)
+		if isText && t.Segment.Start >= lineStop && t.Segment.Len() > 0 {
+			break
+		}
+		para.RemoveChild(para, c)
+		if isText && (t.SoftLineBreak() || t.HardLineBreak() || t.Segment.Stop >= lineStop) {
+			break
+		}
+		c = next
+	}
+	para.Lines().SetSliced(1, para.Lines().Len())
+}
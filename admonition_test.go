@@ -0,0 +1,103 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"testing"
+)
+
+func TestAdmonitions(t *testing.T) {
+	render := func(source string) string {
+		var out bytes.Buffer
+		if err := goldmark.New(goldmark.WithExtensions(&admonitions{})).Convert([]byte(source), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			"alert",
+			"> [!NOTE]\n> Some *text*.\n",
+			"<aside class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n<p>Some <em>text</em>.</p>\n</aside>\n",
+		},
+		{
+			"alert with title",
+			"> [!Warning] Mind the gap\n> Careful.\n",
+			"<aside class=\"admonition admonition-warning\">\n<p class=\"admonition-title\">Mind the gap</p>\n<p>Careful.</p>\n</aside>\n",
+		},
+		{
+			"collapsed alert",
+			"> [!TIP]-\n> Hidden.\n",
+			"<details class=\"admonition admonition-tip\">\n<summary class=\"admonition-title\">Tip</summary>\n<p>Hidden.</p>\n</details>\n",
+		},
+		{
+			"alert alias",
+			"> [!CAUTION]\n> Hot.\n",
+			"<aside class=\"admonition admonition-danger\">\n<p class=\"admonition-title\">Danger</p>\n<p>Hot.</p>\n</aside>\n",
+		},
+		{
+			"unknown alert",
+			"> [!OTHER]\n> Quote.\n",
+			"<blockquote>\n<p>[!OTHER]\nQuote.</p>\n</blockquote>\n",
+		},
+		{
+			"container",
+			":::info Heads up\nSome text.\n\n- item\n:::\n",
+			"<aside class=\"admonition admonition-info\">\n<p class=\"admonition-title\">Heads up</p>\n<p>Some text.</p>\n<ul>\n<li>item</li>\n</ul>\n</aside>\n",
+		},
+		{
+			"closing fence at end of file",
+			":::note\nhi\n:::",
+			"<aside class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n<p>hi</p>\n</aside>\n",
+		},
+		{
+			"opening fence at end of file",
+			":::note",
+			"<aside class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n</aside>\n",
+		},
+		{
+			"expanded container",
+			":::danger+\nText.\n:::\n",
+			"<details class=\"admonition admonition-danger\" open>\n<summary class=\"admonition-title\">Danger</summary>\n<p>Text.</p>\n</details>\n",
+		},
+		{
+			"nested containers",
+			"::::note\n:::tip\nInner.\n:::\nOuter.\n::::\n",
+			"<aside class=\"admonition admonition-note\">\n<p class=\"admonition-title\">Note</p>\n<aside class=\"admonition admonition-tip\">\n<p class=\"admonition-title\">Tip</p>\n<p>Inner.</p>\n</aside>\n<p>Outer.</p>\n</aside>\n",
+		},
+		{
+			"unknown container",
+			":::other\nText.\n:::\n",
+			"<p>:::other\nText.\n:::</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, render(tt.source))
+		})
+	}
+}
+
+func TestParseContainerFence(t *testing.T) {
+	fence, ok := parseContainerFence([]byte("::::warning- Some title\n"), 0)
+	assert.True(t, ok)
+	assert.Equal(t, containerFence{length: 4, name: "warning-", rest: "Some title"}, fence)
+
+	_, ok = parseContainerFence([]byte("::warning\n"), 0)
+	assert.False(t, ok)
+	_, ok = parseContainerFence([]byte("    :::warning\n"), 0)
+	assert.False(t, ok)
+
+	assert.True(t, isContainerFenceEnd([]byte(":::::\n"), 0, 4))
+	assert.False(t, isContainerFenceEnd([]byte(":::\n"), 0, 4))
+	assert.False(t, isContainerFenceEnd([]byte(":::note\n"), 0, 3))
+}
@@ -0,0 +1,75 @@
+package docweaver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// contentCache holds content generated from all products of a repository, such as sitemaps and feeds. Entries are
+// regenerated once the files of any product version change. The zero value is ready to use.
+type contentCache struct {
+	mu      sync.Mutex
+	entries map[string]contentCacheEntry
+}
+
+type contentCacheEntry struct {
+	fingerprint string
+	value       interface{}
+}
+
+// get returns the cached value of the entry with the given name, generating it if it is missing or the products of
+// [repo] changed since. Configuration the value depends on is given as [config].
+func (c *contentCache) get(repo ProductRepository, name, config string, generate func() (interface{}, error)) (interface{}, error) {
+	fingerprint, err := repoFingerprint(repo)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint += ":" + config
+
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && entry.fingerprint == fingerprint {
+		return entry.value, nil
+	}
+
+	value, err := generate()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]contentCacheEntry{}
+	}
+	c.entries[name] = contentCacheEntry{fingerprint: fingerprint, value: value}
+
+	return value, nil
+}
+
+// repoFingerprint returns a hash identifying the current state of all product versions of [repo]. Files are only
+// stat-ed, so it is considerably cheaper than generating content from the pages.
+func repoFingerprint(repo ProductRepository) (string, error) {
+	products, err := repo.FindAllProducts()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for i := range products {
+		product := &products[i]
+		_, _ = fmt.Fprintf(h, "%s:%s\n", product.Key(), product.LatestVersion)
+		for _, version := range product.Versions {
+			fingerprint, err := product.root.versionFingerprint(version)
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "%s:%s\n", version, fingerprint)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
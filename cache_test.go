@@ -0,0 +1,48 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentCache_Get(t *testing.T) {
+	dir := t.TempDir()
+	pagePath := filepath.Join(dir, "product", "1.0", "installation.md")
+	if err := os.MkdirAll(filepath.Dir(pagePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pagePath, []byte("# Installation"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := GetRepository(dir)
+	var cache contentCache
+	generated := 0
+	get := func(config string) interface{} {
+		v, err := cache.get(repo, "sitemap", config, func() (interface{}, error) {
+			generated++
+			return generated, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	assert.Equal(t, 1, get("a"))
+	assert.Equal(t, 1, get("a"))
+
+	assert.Equal(t, 2, get("b"), "configuration changes regenerate the entry")
+
+	modified := time.Now().Add(time.Hour)
+	if err := os.Chtimes(pagePath, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, get("b"), "file changes regenerate the entry")
+	assert.Equal(t, 3, get("b"))
+}
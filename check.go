@@ -0,0 +1,427 @@
+package docweaver
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// CheckOptions configures a link check.
+type CheckOptions struct {
+	External bool          // whether external (http and https) URLs are checked
+	Timeout  time.Duration // timeout of requests to external URLs, defaults to 10 seconds
+}
+
+// Checker checks the links, images and assets of all products, versions and pages.
+type Checker interface {
+	Check() (*CheckReport, error)
+}
+
+// CheckReport is the result of a check.
+type CheckReport struct {
+	Pages  []CheckedPage `json:"pages"`
+	Issues []CheckIssue  `json:"issues"`
+}
+
+// CheckedPage identifies a checked page.
+type CheckedPage struct {
+	Product string `json:"product"`
+	Version string `json:"version"`
+	Page    string `json:"page"`
+}
+
+// CheckIssue is a problem found by a check, such as a broken link.
+type CheckIssue struct {
+	CheckedPage
+	Severity Severity `json:"severity"`
+	Target   string   `json:"target,omitempty"` // link, image or asset URL the issue concerns
+	Message  string   `json:"message"`
+}
+
+type checker struct {
+	repo     ProductRepository
+	options  CheckOptions
+	client   *http.Client
+	pages    map[string]*checkTarget // rendered pages by product/version/page path
+	external map[string]error        // results of external URL checks
+}
+
+// checkTarget is a rendered link target.
+type checkTarget struct {
+	ids map[string]bool
+	err error
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string         `xml:"classname,attr"`
+	Name      string         `xml:"name,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+const (
+	defaultCheckTimeout = 10 * time.Second
+	// checkProductPage is the page of issues concerning a product rather than one of its pages.
+	checkProductPage = ""
+)
+
+// GetChecker returns a Checker for the products of [repo].
+func GetChecker(repo ProductRepository, options CheckOptions) Checker {
+	if repo == nil {
+		repo = GetRepository("")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultCheckTimeout
+	}
+
+	return &checker{
+		repo:     repo,
+		options:  options,
+		client:   &http.Client{Timeout: options.Timeout},
+		pages:    map[string]*checkTarget{},
+		external: map[string]error{},
+	}
+}
+
+// Check renders every page of every product version and checks that internal links lead to existing pages and anchors,
+// that images and assets exist and, if enabled, that external URLs respond successfully. Rendering diagnostics of pages
+// are reported as well.
+func (c *checker) Check() (*CheckReport, error) {
+	report := &CheckReport{}
+	products, err := c.repo.FindAllProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		product := &products[i]
+		if product.ImageUrl != "" {
+			c.checkUrl(report, CheckedPage{Product: product.Key(), Version: product.currentVersion()}, product.ImageUrl, "")
+		}
+
+		for _, version := range product.Versions {
+			pagePaths, err := listPages(c.repo, product.Key(), version)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := os.Stat(product.root.pageFilePath(version, indexPath)); err == nil {
+				pagePaths = append([]string{indexPath}, pagePaths...)
+			}
+			for _, pagePath := range pagePaths {
+				c.checkPage(report, CheckedPage{Product: product.Key(), Version: version, Page: pagePath})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func (c *checker) checkPage(report *CheckReport, source CheckedPage) {
+	report.Pages = append(report.Pages, source)
+	page, err := c.repo.GetPage(source.Product, source.Version, source.Page)
+	if err != nil {
+		report.add(source, SeverityError, "", fmt.Sprintf("Failed to render page. %s", err))
+		return
+	}
+
+	for _, d := range page.Diagnostics {
+		report.add(source, d.Severity, "", d.Message)
+	}
+
+	links, ids := htmlLinks(page.Content)
+	c.pages[c.pageKey(source.Product, source.Version, source.Page)] = &checkTarget{ids: ids}
+	for _, link := range links {
+		c.checkUrl(report, source, link, page.Url())
+	}
+}
+
+// checkUrl checks a link or image URL found on the given page, whose URL is pageUrl.
+func (c *checker) checkUrl(report *CheckReport, source CheckedPage, link, pageUrl string) {
+	u, err := url.Parse(link)
+	if err != nil {
+		report.add(source, SeverityError, link, "Invalid URL.")
+		return
+	}
+
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		if c.options.External {
+			c.checkExternal(report, source, link)
+		}
+	case u.Scheme != "" || u.Host != "":
+		// mailto: and similar links are not checked
+	case u.Path == "" && u.Fragment != "":
+		c.checkInternal(report, source, link, pageUrl+"#"+u.Fragment)
+	case strings.HasPrefix(u.Path, "/"):
+		c.checkInternal(report, source, link, link)
+	default:
+		// relative links left as they are by link rewriting resolve against the page URL, as in browsers
+		base, _ := url.Parse(pageUrl)
+		c.checkInternal(report, source, link, base.ResolveReference(u).String())
+	}
+}
+
+// checkInternal checks a link (or the resolved target of a link) to a page, version, product or asset.
+func (c *checker) checkInternal(report *CheckReport, source CheckedPage, link, target string) {
+	u, _ := url.Parse(target)
+
+	if rest, ok := trimRoutePrefix(u.Path, normalizeRoutePrefix(GetAssetsRoutePrefix())); ok {
+		if !strings.HasPrefix(rest, highlightCssRoute+"/") {
+			c.checkAsset(report, source, link, rest)
+		}
+		return
+	}
+
+	rest, ok := trimRoutePrefix(u.Path, normalizeRoutePrefix(GetRoutePrefix()))
+	if !ok || rest == "" || isFeedFileName(rest) || isSitemapFileName(rest) {
+		return
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) == 2 && isFeedFileName(parts[1]) {
+		return
+	}
+	product, err := c.repo.FindProduct(parts[0])
+	if err != nil || len(product.Versions) == 0 {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken link. Product `%s` does not exist.", parts[0]))
+		return
+	}
+	if len(parts) == 1 {
+		return
+	}
+	if !containsString(product.Versions, parts[1]) {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken link. Version `%s` of product `%s` does not exist.", parts[1], parts[0]))
+		return
+	}
+	if len(parts) == 2 {
+		return
+	}
+	t := c.target(parts[0], parts[1], parts[2])
+	if t.err != nil {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken link. Page `%s` does not exist.", strings.TrimRight(parts[2], "/")))
+		return
+	}
+	if u.Fragment != "" && !t.ids[u.Fragment] {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken link. Anchor `#%s` does not exist.", u.Fragment))
+	}
+}
+
+// checkAsset checks that the file of an asset (product/version/path) exists within the version directory and is
+// published, i.e. is within its images directory.
+func (c *checker) checkAsset(report *CheckReport, source CheckedPage, link, assetPath string) {
+	parts := strings.SplitN(assetPath, "/", 3)
+	if len(parts) < 3 || validateProductKey(parts[0]) != nil || validateVersion(parts[1]) != nil {
+		report.add(source, SeverityError, link, "Broken asset link.")
+		return
+	}
+	if !strings.HasPrefix(path.Clean(parts[2]), imgDirName+"/") {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken asset link. File `%s` is not within the `%s` directory, which is the only one published.", parts[2], imgDirName))
+		return
+	}
+
+	r := productRoot{ParentDir: c.repo.GetDir(), Key: parts[0]}
+	filePath := filepath.Join(r.versionFilePath(parts[1]), filepath.FromSlash(parts[2]))
+	if _, err := confinePath(r.versionFilePath(parts[1]), filePath); err != nil {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken asset link. File `%s` does not exist.", parts[2]))
+	}
+}
+
+func (c *checker) checkExternal(report *CheckReport, source CheckedPage, link string) {
+	err, checked := c.external[link]
+	if !checked {
+		err = c.request(link)
+		c.external[link] = err
+	}
+	if err != nil {
+		report.add(source, SeverityError, link, fmt.Sprintf("Broken external link. %s", err))
+	}
+}
+
+// request requests an external URL, using GET if HEAD requests are not supported.
+func (c *checker) request(link string) error {
+	res, err := c.client.Head(link)
+	if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+		_ = res.Body.Close()
+		res, err = c.client.Get(link)
+	}
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return simpleError{fmt.Sprintf("Responded with status %d.", res.StatusCode)}
+	}
+	return nil
+}
+
+// target returns the rendered link target page, rendering it if it was not checked yet.
+func (c *checker) target(productKey, version, pagePath string) *checkTarget {
+	key := c.pageKey(productKey, version, pagePath)
+	if t, ok := c.pages[key]; ok {
+		return t
+	}
+
+	t := &checkTarget{}
+	if page, err := c.repo.GetPage(productKey, version, pagePath); err != nil {
+		t.err = err
+	} else {
+		_, t.ids = htmlLinks(page.Content)
+	}
+	c.pages[key] = t
+
+	return t
+}
+
+func (c *checker) pageKey(productKey, version, pagePath string) string {
+	pagePath = strings.Trim(pagePath, "/")
+	if pagePath == "" {
+		pagePath = defaultPagePath
+	}
+	return fmt.Sprintf("%s/%s/%s", productKey, version, pagePath)
+}
+
+// htmlLinks returns the link and image URLs and the element IDs of an HTML fragment.
+func htmlLinks(content string) (links []string, ids map[string]bool) {
+	ids = map[string]bool{}
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links, ids
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch {
+				case string(key) == "id":
+					ids[string(val)] = true
+				case string(key) == "href" && string(name) == "a", string(key) == "src" && string(name) == "img":
+					links = append(links, string(val))
+				}
+			}
+		}
+	}
+}
+
+func (r *CheckReport) add(page CheckedPage, severity Severity, target, message string) {
+	r.Issues = append(r.Issues, CheckIssue{CheckedPage: page, Severity: severity, Target: target, Message: message})
+}
+
+// Count returns the number of issues of the given severity.
+func (r *CheckReport) Count(severity Severity) (n int) {
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return
+}
+
+// HasErrors reports whether the check found errors.
+func (r *CheckReport) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+func (p CheckedPage) String() string {
+	if p.Page == checkProductPage {
+		return p.Product
+	}
+	return fmt.Sprintf("%s/%s/%s", p.Product, p.Version, p.Page)
+}
+
+func (i CheckIssue) String() string {
+	if i.Target == "" {
+		return fmt.Sprintf("%s: %s: %s", i.Severity, i.CheckedPage, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", i.Severity, i.CheckedPage, i.Message, i.Target)
+}
+
+// WriteText writes the report as plain text, one issue per line followed by a summary.
+func (r *CheckReport) WriteText(w io.Writer) error {
+	for _, issue := range r.Issues {
+		if _, err := fmt.Fprintln(w, issue); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "Checked %d pages: %d errors, %d warnings.\n", len(r.Pages), r.Count(SeverityError), r.Count(SeverityWarning))
+	return err
+}
+
+// WriteJson writes the report as JSON.
+func (r *CheckReport) WriteJson(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteJUnit writes the report as JUnit XML with a test case per page. Errors are reported as failures and warnings
+// as output of the page's test case; issues concerning a product rather than a page get a test case of their own.
+func (r *CheckReport) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{Name: "docweaver"}
+	cases := map[CheckedPage]int{}
+	addCase := func(page CheckedPage) int {
+		if i, ok := cases[page]; ok {
+			return i
+		}
+		className := page.Product
+		if page.Page != checkProductPage {
+			className = fmt.Sprintf("%s.%s", page.Product, page.Version)
+		}
+		suite.TestCases = append(suite.TestCases, junitTestCase{ClassName: className, Name: page.String()})
+		cases[page] = len(suite.TestCases) - 1
+		return cases[page]
+	}
+
+	for _, page := range r.Pages {
+		addCase(page)
+	}
+	for _, issue := range r.Issues {
+		tc := &suite.TestCases[addCase(issue.CheckedPage)]
+		if issue.Severity == SeverityError {
+			tc.Failures = append(tc.Failures, junitFailure{Message: issue.Message, Type: string(issue.Severity), Text: issue.Target})
+			continue
+		}
+		tc.SystemOut += issue.String() + "\n"
+	}
+	for _, tc := range suite.TestCases {
+		if len(tc.Failures) > 0 {
+			suite.Failures++
+		}
+	}
+	suite.Tests = len(suite.TestCases)
+
+	b, err := marshalXml(suite)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,107 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecker_Check(t *testing.T) {
+	report, err := docweaver.GetChecker(repo, docweaver.CheckOptions{}).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := docweaver.CheckedPage{Product: testProductKey, Version: "main", Page: "guides/deploy"}
+	issue := func(page docweaver.CheckedPage, severity docweaver.Severity, target, message string) docweaver.CheckIssue {
+		return docweaver.CheckIssue{CheckedPage: page, Severity: severity, Target: target, Message: message}
+	}
+
+	assert.True(t, report.HasErrors())
+	assert.Contains(t, report.Pages, docweaver.CheckedPage{Product: testProductKey, Version: "1.0", Page: "documentation"})
+	assert.NotContains(t, report.Pages, docweaver.CheckedPage{Product: testProductKey, Version: "0.9", Page: "documentation"})
+	assert.Contains(t, report.Issues, issue(deploy, docweaver.SeverityWarning, "", "Unknown product `unknown` in link `dw://unknown/latest/setup`."))
+	assert.Contains(t, report.Issues, issue(deploy, docweaver.SeverityError,
+		fmt.Sprintf("/docs/%s/1.0/installation#links", testProductKey), "Broken link. Anchor `#links` does not exist."))
+	assert.Contains(t, report.Issues, issue(deploy, docweaver.SeverityError,
+		fmt.Sprintf("%s/%s/main/images/deploy.png", docweaver.GetAssetsRoutePrefix(), testProductKey), "Broken asset link. File `images/deploy.png` does not exist."))
+	for _, i := range report.Issues {
+		assert.NotEqual(t, fmt.Sprintf("/docs/%s/main/support", testProductKey), i.Target)
+	}
+}
+
+func TestChecker_Check_External(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	docsDir := t.TempDir()
+	versionDir := filepath.Join(docsDir, "ext", "1.0")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	md := fmt.Sprintf("# External\n\n[ok](%s/ok) [missing](%s/missing)\n", server.URL, server.URL)
+	if err := os.WriteFile(filepath.Join(versionDir, "installation.md"), []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extRepo := docweaver.GetRepository(docsDir)
+
+	report, err := docweaver.GetChecker(extRepo, docweaver.CheckOptions{}).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, report.HasErrors())
+
+	report, err = docweaver.GetChecker(extRepo, docweaver.CheckOptions{External: true}).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []docweaver.CheckIssue{{
+		CheckedPage: docweaver.CheckedPage{Product: "ext", Version: "1.0", Page: "installation"},
+		Severity:    docweaver.SeverityError,
+		Target:      server.URL + "/missing",
+		Message:     "Broken external link. Responded with status 404.",
+	}}, report.Issues)
+}
+
+func TestChecker_Check_Unpublished(t *testing.T) {
+	docsDir := t.TempDir()
+	versionDir := filepath.Join(docsDir, "rel", "1.0")
+	for name, content := range map[string]string{
+		"installation.md": "# Relative\n\n[outside](../../outside.md) ![diagram](diagram.svg) ![logo](images/logo.png)\n" +
+			"![other](/assets/rel/1.0/files/a.png) ![escape](/assets/rel/1.0/images/../installation.md)\n",
+		"diagram.svg":     "<svg></svg>",
+		"images/logo.png": "png",
+		"files/a.png":     "png",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(versionDir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(docweaver.EnvKeyAssetsRoutePrefix, "/assets")
+
+	report, err := docweaver.GetChecker(docweaver.GetRepository(docsDir), docweaver.CheckOptions{}).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var targets []string
+	for _, i := range report.Issues {
+		if i.Severity == docweaver.SeverityError {
+			targets = append(targets, i.Target)
+		}
+	}
+	assert.ElementsMatch(t, []string{"../../outside.md", "diagram.svg", "/assets/rel/1.0/files/a.png", "/assets/rel/1.0/images/../installation.md"}, targets)
+}
@@ -0,0 +1,54 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHtmlLinks(t *testing.T) {
+	links, ids := htmlLinks(`<h2 id="setup">Setup</h2><p><a href="/docs/foo/1.0/support#help">help</a> <img src="/doc-assets/foo/1.0/a.png" alt="a"/></p><a name="x">x</a><span href="/ignored" id="note"></span>`)
+
+	assert.Equal(t, []string{"/docs/foo/1.0/support#help", "/doc-assets/foo/1.0/a.png"}, links)
+	assert.Equal(t, map[string]bool{"setup": true, "note": true}, ids)
+}
+
+func TestCheckReport(t *testing.T) {
+	page := CheckedPage{Product: "foo", Version: "1.0", Page: "installation"}
+	report := &CheckReport{Pages: []CheckedPage{page, {Product: "foo", Version: "1.0", Page: "support"}}}
+	report.add(page, SeverityError, "/docs/foo/1.0/missing", "Broken link. Page `missing` does not exist.")
+	report.add(page, SeverityWarning, "", "Unknown variable `var.x`.")
+	report.add(CheckedPage{Product: "foo", Version: "1.0"}, SeverityError, "/doc-assets/foo/1.0/logo.png", "Broken asset link.")
+
+	assert.True(t, report.HasErrors())
+	assert.Equal(t, 2, report.Count(SeverityError))
+	assert.Equal(t, 1, report.Count(SeverityWarning))
+
+	t.Run("text", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, report.WriteText(&b))
+		assert.Equal(t, "error: foo/1.0/installation: Broken link. Page `missing` does not exist. (/docs/foo/1.0/missing)\n"+
+			"warning: foo/1.0/installation: Unknown variable `var.x`.\n"+
+			"error: foo: Broken asset link. (/doc-assets/foo/1.0/logo.png)\n"+
+			"Checked 2 pages: 2 errors, 1 warnings.\n", b.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, report.WriteJson(&b))
+		assert.Contains(t, b.String(), `"severity": "error"`)
+		assert.Contains(t, b.String(), `"target": "/docs/foo/1.0/missing"`)
+		assert.Contains(t, b.String(), `"page": "installation"`)
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var b bytes.Buffer
+		assert.NoError(t, report.WriteJUnit(&b))
+		assert.Contains(t, b.String(), `<testsuite name="docweaver" tests="3" failures="2">`)
+		assert.Contains(t, b.String(), `<testcase classname="foo.1.0" name="foo/1.0/support"></testcase>`)
+		assert.Contains(t, b.String(), `<failure message="Broken link. Page `+"`missing`"+` does not exist." type="error">/docs/foo/1.0/missing</failure>`)
+		assert.Contains(t, b.String(), `<testcase classname="foo" name="foo">`)
+	})
+}
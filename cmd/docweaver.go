@@ -1,18 +1,17 @@
 package main
 
 import (
+	"flag"
 	"github.com/reliqarts/go-docweaver"
 	"log"
 	"os"
 )
 
-var publisher = docweaver.GetPublisher()
-
 func main() {
 	args := os.Args[1:]
 
 	if len(args) < 1 {
-		log.Fatal("One or more arguments missing. Usage: `docweaver (publish productName productSource [shouldUpdate=true])|(update [...productNames])`")
+		log.Fatal("One or more arguments missing. Usage: `docweaver (publish productName productSource [shouldUpdate=true])|(update [...productNames])|index|(export --out dir [--template dir] [--incremental] [--prerender-diagrams])|(check [--format text|json|junit] [--external])`")
 	}
 
 	action := args[0]
@@ -23,19 +22,28 @@ func main() {
 	case "publish":
 		publish(args[1:]...)
 		return
+	case "index":
+		index()
+		return
+	case "export":
+		export(args[1:]...)
+		return
+	case "check":
+		check(args[1:]...)
+		return
 	default:
-		log.Fatalf("Invalid action given: `%s`. Must be 'publish' or 'update'.", action)
+		log.Fatalf("Invalid action given: `%s`. Must be 'publish', 'update', 'index', 'export' or 'check'.", action)
 	}
 }
 
 func update(args ...string) {
 	if len(args) == 0 {
 		log.Println("No product names given for update. All products will be updated.")
-		publisher.UpdateAll()
+		getPublisher().UpdateAll()
 		return
 	}
 
-	publisher.Update(args...)
+	getPublisher().Update(args...)
 }
 
 func publish(args ...string) {
@@ -50,5 +58,85 @@ func publish(args ...string) {
 		shouldUpdate = false
 	}
 
-	publisher.Publish(args[0], args[1], shouldUpdate)
+	getPublisher().Publish(args[0], args[1], shouldUpdate)
+}
+
+// getPublisher returns a publisher keeping the search index (DW_SEARCH_INDEX_FILE) up to date with published versions.
+func getPublisher() docweaver.UpdaterPublisher {
+	searcher, err := docweaver.GetSearcher(nil, "")
+	if err != nil {
+		log.Fatalf("Failed to load search index. %s", err)
+	}
+
+	return docweaver.GetPublisherWithSearcher("", searcher)
+}
+
+func index() {
+	searcher, err := docweaver.GetSearcher(nil, "")
+	if err != nil {
+		log.Fatalf("Failed to load search index. %s", err)
+	}
+	if err := searcher.IndexAll(); err != nil {
+		log.Fatalf("Failed to index products. %s", err)
+	}
+	if err := searcher.Save(); err != nil {
+		log.Fatalf("Failed to save search index. %s", err)
+	}
+
+	log.Printf("Search index saved to `%s`.", docweaver.GetSearchIndexFile())
+}
+
+func export(args ...string) {
+	var options docweaver.ExportOptions
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&options.OutDir, "out", "", "directory exported files are written to")
+	flags.StringVar(&options.TemplateDir, "template", "", "directory of html/template layouts")
+	flags.BoolVar(&options.Incremental, "incremental", false, "only re-render versions changed since the previous export")
+	flags.BoolVar(&options.PrerenderDiagrams, "prerender-diagrams", false, "render graphviz diagrams to inline SVG")
+	_ = flags.Parse(args)
+
+	if options.OutDir == "" {
+		log.Fatal("Output directory missing for export action. Usage: `export --out dir [--template dir] [--incremental] [--prerender-diagrams]`")
+	}
+
+	exporter, err := docweaver.GetExporter(nil, options)
+	if err != nil {
+		log.Fatalf("Failed to initialize export. %s", err)
+	}
+	if err := exporter.Export(); err != nil {
+		log.Fatalf("Failed to export products. %s", err)
+	}
+
+	log.Printf("Exported products to `%s`.", options.OutDir)
+}
+
+func check(args ...string) {
+	var options docweaver.CheckOptions
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	format := flags.String("format", "text", "report format: text, json or junit")
+	flags.BoolVar(&options.External, "external", false, "also check external links")
+	flags.DurationVar(&options.Timeout, "timeout", 0, "timeout of requests to external links (default 10s)")
+	_ = flags.Parse(args)
+	// the report is written to stdout, so logs must not be
+	docweaver.SetLogOutput(os.Stderr)
+
+	write := map[string]func(*docweaver.CheckReport) error{
+		"text":  func(r *docweaver.CheckReport) error { return r.WriteText(os.Stdout) },
+		"json":  func(r *docweaver.CheckReport) error { return r.WriteJson(os.Stdout) },
+		"junit": func(r *docweaver.CheckReport) error { return r.WriteJUnit(os.Stdout) },
+	}[*format]
+	if write == nil {
+		log.Fatalf("Invalid report format given: `%s`. Must be 'text', 'json' or 'junit'.", *format)
+	}
+
+	report, err := docweaver.GetChecker(nil, options).Check()
+	if err != nil {
+		log.Fatalf("Failed to check products. %s", err)
+	}
+	if err := write(report); err != nil {
+		log.Fatalf("Failed to write check report. %s", err)
+	}
+	if report.HasErrors() {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,64 @@
+//go:build integration || ci
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+const envKeyRunMain = "DW_TEST_RUN_MAIN"
+
+// TestMain runs the command instead of the tests if requested, so tests may run it as a separate process.
+func TestMain(m *testing.M) {
+	if os.Getenv(envKeyRunMain) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// run runs the command with the given arguments, returning its standard output and error.
+func run(t *testing.T, args ...string) (stdout, stderr []byte) {
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), envKeyRunMain+"=1", docweaver.EnvKeyDocsDir+"=../testdata/docs")
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Fatal(err)
+		}
+	}
+
+	return out.Bytes(), errOut.Bytes()
+}
+
+func TestCheck_Json(t *testing.T) {
+	stdout, stderr := run(t, "check", "--format", "json")
+
+	var report docweaver.CheckReport
+	if err := json.Unmarshal(stdout, &report); err != nil {
+		t.Fatalf("Output is not a JSON report. %s\n%s", err, stdout)
+	}
+	assert.Contains(t, report.Pages, docweaver.CheckedPage{Product: "product1", Version: "1.0", Page: "documentation"})
+	assert.NotEmpty(t, report.Issues)
+	assert.Contains(t, string(stderr), "[Dw][warn]", "log messages are expected on standard error")
+}
+
+func TestCheck_JUnit(t *testing.T) {
+	stdout, _ := run(t, "check", "--format", "junit")
+
+	var suite struct {
+		Tests int `xml:"tests,attr"`
+	}
+	if err := xml.Unmarshal(stdout, &suite); err != nil {
+		t.Fatalf("Output is not a JUnit report. %s\n%s", err, stdout)
+	}
+	assert.Positive(t, suite.Tests)
+}
@@ -0,0 +1,30 @@
+package docweaver
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+// Diagnostic severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while rendering a page, such as a failed include or an unknown variable.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return string(d.Severity) + ": " + d.Message
+}
+
+// hasErrors reports whether any of the given diagnostics is an error.
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
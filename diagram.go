@@ -0,0 +1,161 @@
+package docweaver
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Diagram is a diagram written as a fenced code block of a diagram language (```mermaid, ```dot or ```graphviz).
+type Diagram struct {
+	ast.BaseBlock
+	Lang string // mermaid or dot
+}
+
+// KindDiagram is the ast.NodeKind of Diagram nodes.
+var KindDiagram = ast.NewNodeKind("Diagram")
+
+// diagrams renders diagrams as containers for client-side rendering: `<div class="diagram mermaid">` and
+// `<div class="diagram graphviz">`, holding the diagram's source.
+type diagrams struct{}
+
+type diagramTransformer struct{}
+
+const (
+	diagramLangMermaid = "mermaid"
+	diagramLangDot     = "dot"
+
+	// versions of the diagram libraries loaded by DiagramsScript
+	mermaidVersion = "10.9.1"
+	vizVersion     = "3.2.4"
+)
+
+// diagramLangs maps the languages of fenced code blocks holding diagrams to their diagram language.
+var diagramLangs = map[string]string{
+	"mermaid":  diagramLangMermaid,
+	"dot":      diagramLangDot,
+	"graphviz": diagramLangDot,
+}
+
+var graphvizContainerPattern = regexp.MustCompile(`<div class="diagram graphviz">([^<]*)</div>`)
+
+// DiagramsScript renders diagram containers with mermaid and Viz.js, which are only loaded by pages holding diagrams of
+// their language. Both are pinned versions loaded from the default CDN; the `diagramsScript` template function, which
+// the built-in page template includes, loads them from the URLs configured via DW_MERMAID_URL and DW_VIZ_URL instead.
+const DiagramsScript = `<script>
+(function () {
+  if (document.querySelector('.diagram.mermaid')) {
+    import('` + defaultMermaidUrl + `').then(function (m) {
+      m.default.initialize({startOnLoad: false});
+      m.default.run({querySelector: '.diagram.mermaid'});
+    });
+  }
+  if (document.querySelector('.diagram.graphviz')) {
+    var script = document.createElement('script');
+    script.src = '` + defaultVizUrl + `';
+    script.onload = function () {
+      Viz.instance().then(function (viz) {
+        document.querySelectorAll('.diagram.graphviz').forEach(function (el) {
+          try { el.replaceChildren(viz.renderSVGElement(el.textContent)); } catch (e) {}
+        });
+      });
+    };
+    document.head.appendChild(script);
+  }
+})();
+</script>`
+
+func (n *Diagram) Kind() ast.NodeKind {
+	return KindDiagram
+}
+
+func (n *Diagram) IsRaw() bool {
+	return true
+}
+
+func (n *Diagram) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Lang": n.Lang}, nil)
+}
+
+// diagramsScript returns DiagramsScript, loading the diagram libraries from the configured URLs, for use in templates.
+func diagramsScript() template.HTML {
+	return template.HTML(strings.NewReplacer(
+		defaultMermaidUrl, template.JSEscapeString(GetMermaidUrl()),
+		defaultVizUrl, template.JSEscapeString(GetVizUrl()),
+	).Replace(DiagramsScript))
+}
+
+func (d *diagrams) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(&diagramTransformer{}, 120)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(d, 100)))
+}
+
+func (d *diagrams) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindDiagram, d.renderDiagram)
+}
+
+func (d *diagrams) renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*Diagram)
+	class := n.Lang
+	if n.Lang == diagramLangDot {
+		class = "graphviz"
+	}
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		code.Write(seg.Value(source))
+	}
+	_, _ = fmt.Fprintf(w, "<div class=\"diagram %s\">%s</div>\n", class, html.EscapeString(code.String()))
+
+	return ast.WalkSkipChildren, nil
+}
+
+// Transform replaces fenced code blocks of diagram languages by diagrams. It runs after tabs are grouped, so diagrams
+// may be shown in tabs.
+func (t *diagramTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if block, ok := n.(*ast.FencedCodeBlock); ok && entering && block.Info != nil {
+			if _, isDiagram := diagramLangs[parseFenceInfo(string(block.Info.Segment.Value(source))).Lang]; isDiagram {
+				blocks = append(blocks, block)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		diagram := &Diagram{Lang: diagramLangs[parseFenceInfo(string(block.Info.Segment.Value(source))).Lang]}
+		diagram.SetLines(block.Lines())
+		block.Parent().ReplaceChild(block.Parent(), block, diagram)
+	}
+}
+
+// prerenderDiagrams replaces the graphviz diagram containers of rendered content by inline SVG. Diagrams which fail to
+// render are left for client-side rendering.
+func prerenderDiagrams(content string) string {
+	return graphvizContainerPattern.ReplaceAllStringFunc(content, func(container string) string {
+		source := html.UnescapeString(graphvizContainerPattern.FindStringSubmatch(container)[1])
+		svg, err := dotToSvg(source)
+		if err != nil {
+			log(lWarn, "Failed to pre-render diagram. %s\n", err)
+			return container
+		}
+		return fmt.Sprintf("<div class=\"diagram graphviz-svg\">%s</div>", svg)
+	})
+}
@@ -0,0 +1,65 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"testing"
+)
+
+func TestDiagrams(t *testing.T) {
+	render := func(source string) string {
+		var out bytes.Buffer
+		gm := goldmark.New(goldmark.WithExtensions(&diagrams{}, &tabs{}))
+		if err := gm.Convert([]byte(source), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	t.Run("mermaid", func(t *testing.T) {
+		assert.Equal(t, "<div class=\"diagram mermaid\">graph TD\n  A --&gt; B\n</div>\n", render("```mermaid\ngraph TD\n  A --> B\n```\n"))
+	})
+
+	t.Run("graphviz", func(t *testing.T) {
+		expected := "<div class=\"diagram graphviz\">digraph { a -&gt; b }\n</div>\n"
+		assert.Equal(t, expected, render("```dot\ndigraph { a -> b }\n```\n"))
+		assert.Equal(t, expected, render("```graphviz title=\"x\"\ndigraph { a -> b }\n```\n"))
+	})
+
+	t.Run("other code", func(t *testing.T) {
+		assert.Equal(t, "<pre><code class=\"language-go\">x := 1\n</code></pre>\n", render("```go\nx := 1\n```\n"))
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+		out := render("```mermaid tab=Mermaid\ngraph TD\n```\n```dot tab=DOT\ngraph { a }\n```\n")
+
+		assert.Contains(t, out, "role=\"tablist\"")
+		assert.Contains(t, out, "<div class=\"diagram mermaid\">graph TD\n</div>")
+		assert.Contains(t, out, "<div class=\"diagram graphviz\">graph { a }\n</div>")
+	})
+}
+
+func TestPrerenderDiagrams(t *testing.T) {
+	content := "<p>x</p>\n<div class=\"diagram graphviz\">digraph { a -&gt; b }\n</div>\n" +
+		"<div class=\"diagram graphviz\">digraph { a -&gt; </div>\n<div class=\"diagram mermaid\">graph TD</div>\n"
+	out := prerenderDiagrams(content)
+
+	assert.Contains(t, out, "<p>x</p>\n<div class=\"diagram graphviz-svg\"><svg xmlns=\"http://www.w3.org/2000/svg\"")
+	assert.Contains(t, out, "<div class=\"diagram graphviz\">digraph { a -&gt; </div>")
+	assert.Contains(t, out, "<div class=\"diagram mermaid\">graph TD</div>")
+}
+
+func TestDiagramsScript(t *testing.T) {
+	assert.Contains(t, DiagramsScript, "import('https://cdn.jsdelivr.net/npm/mermaid@"+mermaidVersion+"/dist/mermaid.esm.min.mjs')")
+	assert.Contains(t, DiagramsScript, "script.src = 'https://cdn.jsdelivr.net/npm/@viz-js/viz@"+vizVersion+"/lib/viz-standalone.js';")
+
+	t.Setenv(EnvKeyMermaidUrl, "/assets/mermaid.esm.min.mjs")
+	t.Setenv(EnvKeyVizUrl, "/assets/viz-standalone.js")
+	script := string(diagramsScript())
+	assert.Contains(t, script, "import('/assets/mermaid.esm.min.mjs')")
+	assert.Contains(t, script, "script.src = '/assets/viz-standalone.js';")
+	assert.NotContains(t, script, "cdn.jsdelivr.net")
+}
@@ -0,0 +1,402 @@
+package docweaver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// directiveExpander expands directives within the markdown of a page before it is rendered. Directives are written on
+// a line of their own, outside of fenced code blocks:
+//
+//	{{< include "partials/requirements.md" >}}
+//	{{< snippet "examples/main.go" region="setup" >}}
+//	{{< snippet "examples/main.go" lines="5-12" >}}
+//
+// Paths are resolved relative to the version root and may not leave it. Directives which fail are replaced by a
+// visible error.
+type directiveExpander struct {
+	root        string   // resolved version root
+	stack       []string // resolved paths of the files being expanded, outermost first
+	diagnostics []Diagnostic
+}
+
+const (
+	includeDirective = "include"
+	snippetDirective = "snippet"
+	maxIncludeDepth  = 10
+	// partialsDirName is the default directory holding included files rather than pages.
+	partialsDirName = "partials"
+)
+
+var (
+	directivePattern     = regexp.MustCompile(`^([ \t]*)\{\{<\s*(\w+)\s+"([^"]+)"((?:\s+[\w-]+="[^"]*")*)\s*>\}\}[ \t]*$`)
+	regionStartPattern   = regexp.MustCompile(`^\s*(?://|#|--|;|/\*|<!--)\s*region:?\s+([\w.-]+)`)
+	regionEndPattern     = regexp.MustCompile(`^\s*(?://|#|--|;|/\*|<!--)\s*endregion\b`)
+	backtickFencePattern = regexp.MustCompile("`{3,}")
+	// inlineLinkDestPattern and linkRefDefPattern match the destinations of inline links and images, and of link
+	// reference definitions.
+	inlineLinkDestPattern = regexp.MustCompile(`(\]\(\s*)([^\s()<>]+)`)
+	linkRefDefPattern     = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(\S+)`)
+)
+
+// expandDirectives expands the directives of [md], the markdown body of the page at [filePath], resolving paths
+// relative to [root]. Failed directives are reported as error diagnostics.
+func expandDirectives(root, filePath string, md []byte) ([]byte, []Diagnostic) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return md, []Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+	}
+
+	e := &directiveExpander{root: resolvedRoot}
+	out := e.expand(filePath, md)
+
+	return out, e.diagnostics
+}
+
+func (e *directiveExpander) expand(filePath string, md []byte) []byte {
+	e.stack = append(e.stack, filePath)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+
+	var out bytes.Buffer
+	fence := ""
+	for rest := md; len(rest) > 0; {
+		var line []byte
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i+1], rest[i+1:]
+		} else {
+			line, rest = rest, nil
+		}
+
+		if fence = codeFence(string(line), fence); fence != "" {
+			out.Write(line)
+			continue
+		}
+
+		if m := directivePattern.FindSubmatch(bytes.TrimRight(line, "\r\n")); m != nil {
+			attrs := parseFenceInfo(string(m[4])).Attrs
+			switch string(m[2]) {
+			case includeDirective:
+				out.Write(indentLines(e.include(string(m[3])), string(m[1])))
+				continue
+			case snippetDirective:
+				out.Write(indentLines(e.snippet(string(m[3]), attrs), string(m[1])))
+				continue
+			}
+		}
+		out.Write(line)
+	}
+
+	return out.Bytes()
+}
+
+// include returns the expanded markdown of the file at the given path, without its front matter.
+func (e *directiveExpander) include(path string) []byte {
+	if len(e.stack) > maxIncludeDepth {
+		return e.fail(includeDirective, path, fmt.Sprintf("Maximum include depth (%d) exceeded.", maxIncludeDepth))
+	}
+
+	resolved, err := e.resolve(path)
+	if err != nil {
+		return e.fail(includeDirective, path, err.Error())
+	}
+	for i, f := range e.stack {
+		if f == resolved {
+			cycle := append(append([]string{}, e.stack[i:]...), resolved)
+			for j := range cycle {
+				cycle[j] = e.rel(cycle[j])
+			}
+			return e.fail(includeDirective, path, fmt.Sprintf("Include cycle: %s.", strings.Join(cycle, " -> ")))
+		}
+	}
+
+	md, err := os.ReadFile(resolved)
+	if err != nil {
+		return e.fail(includeDirective, path, err.Error())
+	}
+	_, body := splitFrontMatter(md)
+	body = e.expand(resolved, body)
+	body = rebaseLinks(body, e.rel(resolved), e.rel(e.stack[len(e.stack)-1]))
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		body = append(body, '\n')
+	}
+
+	return body
+}
+
+// rebaseLinks rewrites the relative link and image destinations of [md], the content of the file [fromFile], to be
+// relative to the file [toFile] it is included in. Both are slash separated paths relative to the version root.
+// Included files may thus link relative to their own directory. Destinations within fenced code blocks and those
+// leaving the version root are left unchanged.
+func rebaseLinks(md []byte, fromFile, toFile string) []byte {
+	from, to := path.Dir(fromFile), path.Dir(toFile)
+	if from == to {
+		return md
+	}
+
+	rebase := func(destination string) string {
+		u, ok := relativeUrl(destination)
+		if !ok {
+			return destination
+		}
+		target := path.Join(from, u.Path)
+		if target == ".." || strings.HasPrefix(target, "../") {
+			return destination
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(to), filepath.FromSlash(target))
+		if err != nil {
+			return destination
+		}
+		suffix := ""
+		if i := strings.IndexAny(destination, "?#"); i >= 0 {
+			suffix = destination[i:]
+		}
+
+		return filepath.ToSlash(rel) + suffix
+	}
+	replace := func(pattern *regexp.Regexp, line string) string {
+		return pattern.ReplaceAllStringFunc(line, func(m string) string {
+			sm := pattern.FindStringSubmatch(m)
+			return sm[1] + rebase(sm[2])
+		})
+	}
+
+	var out strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(string(md), "\n") {
+		if fence = codeFence(line, fence); fence == "" {
+			line = replace(linkRefDefPattern, replace(inlineLinkDestPattern, line))
+		}
+		out.WriteString(line)
+	}
+
+	return []byte(out.String())
+}
+
+// snippet returns a fenced code block holding the lines (`lines="5-12"`) or the named region (`region="setup"`) of the
+// file at the given path, or the whole file. The language is inferred from the file name unless given via `lang`;
+// other attributes are added to the fence info.
+func (e *directiveExpander) snippet(path string, attrs map[string]string) []byte {
+	resolved, err := e.resolve(path)
+	if err != nil {
+		return e.fail(snippetDirective, path, err.Error())
+	}
+	b, err := os.ReadFile(resolved)
+	if err != nil {
+		return e.fail(snippetDirective, path, err.Error())
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(string(b), "\n"), "\n")
+	switch {
+	case attrs["region"] != "":
+		if lines, err = snippetRegion(lines, attrs["region"]); err != nil {
+			return e.fail(snippetDirective, path, err.Error())
+		}
+	case attrs["lines"] != "":
+		if lines, err = snippetLines(lines, attrs["lines"]); err != nil {
+			return e.fail(snippetDirective, path, err.Error())
+		}
+	}
+	code := strings.Join(dedentLines(lines), "")
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+
+	info := attrs["lang"]
+	if info == "" {
+		info = snippetLang(path)
+	}
+	for _, key := range sortedKeys(attrs) {
+		if key != "region" && key != "lines" && key != "lang" {
+			info += fmt.Sprintf(" %s=%q", key, attrs[key])
+		}
+	}
+
+	fence := "```"
+	for _, f := range backtickFencePattern.FindAllString(code, -1) {
+		if len(f) >= len(fence) {
+			fence = strings.Repeat("`", len(f)+1)
+		}
+	}
+
+	return []byte(fmt.Sprintf("%s%s\n%s%s\n", fence, strings.TrimSpace(info), code, fence))
+}
+
+// snippetRegion returns the lines of the named region, excluding the region markers of nested regions.
+func snippetRegion(lines []string, name string) ([]string, error) {
+	var region []string
+	depth := 0
+	for _, line := range lines {
+		if m := regionStartPattern.FindStringSubmatch(line); m != nil {
+			if depth > 0 {
+				depth++
+			} else if m[1] == name {
+				depth = 1
+			}
+			continue
+		}
+		if regionEndPattern.MatchString(line) {
+			if depth == 1 {
+				return region, nil
+			}
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth > 0 {
+			region = append(region, line)
+		}
+	}
+
+	if depth > 0 {
+		return nil, simpleError{fmt.Sprintf("Region `%s` is not closed.", name)}
+	}
+	return nil, simpleError{fmt.Sprintf("Region `%s` does not exist.", name)}
+}
+
+// snippetLines returns the given line ranges (e.g. "5-12" or "1,3-4") of lines.
+func snippetLines(lines []string, spec string) ([]string, error) {
+	ranges := parseLineRanges(spec)
+	if len(ranges) == 0 {
+		return nil, simpleError{fmt.Sprintf("Invalid line range `%s`.", spec)}
+	}
+
+	var selected []string
+	for _, r := range ranges {
+		if r[0] < 1 || r[1] > len(lines) {
+			return nil, simpleError{fmt.Sprintf("Line range `%d-%d` is out of bounds; the file has %d lines.", r[0], r[1], len(lines))}
+		}
+		selected = append(selected, lines[r[0]-1:r[1]]...)
+	}
+
+	return selected, nil
+}
+
+// snippetLang returns the language of a fenced code block of the file at the given path.
+func snippetLang(path string) string {
+	if lexer := lexers.Match(filepath.Base(path)); lexer != nil && len(lexer.Config().Aliases) > 0 {
+		return lexer.Config().Aliases[0]
+	}
+	return strings.TrimPrefix(filepath.Ext(path), ".")
+}
+
+// dedentLines removes the indentation common to all non-blank lines.
+func dedentLines(lines []string) []string {
+	indent := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first || !strings.HasPrefix(lineIndent, indent) {
+			if first {
+				indent = lineIndent
+			} else {
+				indent = commonPrefix(indent, lineIndent)
+			}
+			first = false
+		}
+	}
+	if indent == "" {
+		return lines
+	}
+
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		dedented[i] = strings.TrimPrefix(line, indent)
+	}
+	return dedented
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for ; i < len(a) && i < len(b) && a[i] == b[i]; i++ {
+	}
+	return a[:i]
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resolve resolves a directive path relative to the version root.
+func (e *directiveExpander) resolve(path string) (string, error) {
+	target := filepath.Join(e.root, filepath.FromSlash(path))
+	if filepath.IsAbs(filepath.FromSlash(path)) || !isWithin(e.root, target) {
+		return "", ValidationError{Field: FieldPath, Value: path, Reason: "Path must be within the version directory."}
+	}
+
+	resolved, err := confinePath(e.root, target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", simpleError{"File does not exist."}
+	}
+
+	return resolved, err
+}
+
+// rel returns the given path relative to the version root.
+func (e *directiveExpander) rel(path string) string {
+	if rel, err := filepath.Rel(e.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// fail records a failed directive and returns markdown displaying the error.
+func (e *directiveExpander) fail(directive, arg, reason string) []byte {
+	err := simpleError{fmt.Sprintf("Failed to %s `%s` in `%s`. %s", directive, arg, e.rel(e.stack[len(e.stack)-1]), reason)}
+	e.diagnostics = append(e.diagnostics, Diagnostic{Severity: SeverityError, Message: err.Error()})
+
+	return []byte(fmt.Sprintf("\n<div class=\"docweaver-error\" role=\"alert\">%s</div>\n\n", html.EscapeString(err.Error())))
+}
+
+// codeFence returns the fence of the fenced code block open after [line], given the fence open before it.
+func codeFence(line, open string) string {
+	trimmed := strings.TrimLeft(line, " \t")
+	if open == "" {
+		for _, c := range []string{"```", "~~~"} {
+			if strings.HasPrefix(trimmed, c) {
+				return trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, c[:1]))]
+			}
+		}
+		return ""
+	}
+
+	if strings.HasPrefix(trimmed, open) && strings.TrimSpace(strings.TrimLeft(trimmed, open[:1])) == "" {
+		return ""
+	}
+	return open
+}
+
+// indentLines prefixes all non-empty lines of md with indent.
+func indentLines(md []byte, indent string) []byte {
+	if indent == "" {
+		return md
+	}
+
+	lines := strings.SplitAfter(string(md), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+
+	return []byte(strings.Join(lines, ""))
+}
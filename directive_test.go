@@ -0,0 +1,161 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandDirectives(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"page.md":                  "# Page\n",
+		"partials/a.md":            "---\ntitle: A\n---\nA says hi.\n{{< include \"partials/b.md\" >}}\n",
+		"partials/b.md":            "B says hi.",
+		"partials/self.md":         "{{< include \"partials/self.md\" >}}\n",
+		"partials/loop-1.md":       "{{< include \"partials/loop-2.md\" >}}\n",
+		"partials/loop-2.md":       "{{< include \"partials/loop-1.md\" >}}\n",
+		"partials/item.md":         "- item\n\n  more\n",
+		"../outside.md":            "secret\n",
+		"examples/main.go":         "package main\n\nfunc main() {\n\t// region: setup\n\tsetup()\n\t// region: inner\n\tinner()\n\t// endregion\n\n\tdone()\n\t// endregion\n}\n",
+		"examples/script.py":       "# region: greet\nprint(\"```\")\n# endregion\n",
+		"partials/nested/x.md":     "{{< include \"../outside.md\" >}}\n",
+		"partials/links.md":        "See [git](git.md#setup) and ![logo](../images/logo.png).\n{{< include \"partials/nested/links.md\" >}}\n",
+		"partials/nested/links.md": "[x]: ../../images/x.png\n",
+	}
+	for i := 0; i <= maxIncludeDepth; i++ {
+		files[fmt.Sprintf("partials/depth-%d.md", i)] = fmt.Sprintf("{{< include \"partials/depth-%d.md\" >}}\n", i+1)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := filepath.Join(root, "page.md")
+	expand := func(md string) (string, []Diagnostic) {
+		out, diagnostics := expandDirectives(root, page, []byte(md))
+		return string(out), diagnostics
+	}
+
+	t.Run("include", func(t *testing.T) {
+		out, errs := expand("Intro\n\n{{< include \"partials/a.md\" >}}\nOutro\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "Intro\n\nA says hi.\nB says hi.\nOutro\n", out)
+	})
+
+	t.Run("relative links", func(t *testing.T) {
+		out, errs := expand("{{< include \"partials/links.md\" >}}\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "See [git](partials/git.md#setup) and ![logo](images/logo.png).\n[x]: images/x.png\n", out)
+	})
+
+	t.Run("indented", func(t *testing.T) {
+		out, errs := expand("1. Step\n\n   {{< include \"partials/item.md\" >}}\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "1. Step\n\n   - item\n\n     more\n", out)
+	})
+
+	t.Run("code block", func(t *testing.T) {
+		md := "```markdown\n{{< include \"partials/b.md\" >}}\n```\n"
+		out, errs := expand(md)
+
+		assert.Empty(t, errs)
+		assert.Equal(t, md, out)
+	})
+
+	t.Run("snippet region", func(t *testing.T) {
+		out, errs := expand("{{< snippet \"examples/main.go\" region=\"setup\" >}}\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "```go\nsetup()\ninner()\n\ndone()\n```\n", out)
+	})
+
+	t.Run("snippet lines", func(t *testing.T) {
+		out, errs := expand("- Item\n\n  {{< snippet \"examples/main.go\" lines=\"1,3\" lang=\"golang\" tab=\"Go\" >}}\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "- Item\n\n  ```golang tab=\"Go\"\n  package main\n  func main() {\n  ```\n", out)
+	})
+
+	t.Run("snippet with fence", func(t *testing.T) {
+		out, errs := expand("{{< snippet \"examples/script.py\" region=\"greet\" >}}\n")
+
+		assert.Empty(t, errs)
+		assert.Equal(t, "````python\nprint(\"```\")\n````\n", out)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		tests := []struct {
+			directive string
+			reason    string
+		}{
+			{`include "partials/missing.md"`, "File does not exist."},
+			{`include "../outside.md"`, "within the version directory"},
+			{`include "/etc/passwd"`, "within the version directory"},
+			{`include "partials/nested/x.md"`, "within the version directory"},
+			{`include "partials/self.md"`, "Include cycle: partials/self.md -> partials/self.md."},
+			{`include "partials/depth-0.md"`, "Maximum include depth (10) exceeded."},
+			{`include "partials/loop-1.md"`, "Include cycle: partials/loop-1.md -> partials/loop-2.md -> partials/loop-1.md."},
+			{`snippet "examples/main.go" region="missing"`, "Region `missing` does not exist."},
+			{`snippet "examples/main.go" lines="10-20"`, "out of bounds"},
+			{`snippet "examples/main.go" lines="x"`, "Invalid line range"},
+			{`snippet "../outside.md"`, "within the version directory"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.directive, func(t *testing.T) {
+				out, errs := expand("{{< " + tt.directive + " >}}\n")
+
+				if assert.Len(t, errs, 1) {
+					assert.Equal(t, SeverityError, errs[0].Severity)
+					assert.Contains(t, errs[0].Message, tt.reason)
+				}
+				assert.Contains(t, out, "<div class=\"docweaver-error\" role=\"alert\">")
+				assert.NotContains(t, out, "secret")
+			})
+		}
+	})
+}
+
+func TestRebaseLinks(t *testing.T) {
+	tests := []struct {
+		md       string
+		expected string
+	}{
+		{"[a](b.md) and [c](./d/e.md?x=1#f)", "[a](../partials/b.md) and [c](../partials/d/e.md?x=1#f)"},
+		{"![img](../images/a.png \"Title\")", "![img](../images/a.png \"Title\")"},
+		{"![img](img/a.png)", "![img](../partials/img/a.png)"},
+		{"[ref]: b.md#top", "[ref]: ../partials/b.md#top"},
+		{"[abs](/docs/x) [ext](https://example.com/a.md) [anchor](#a) [tpl]({{docs}}/x)", "[abs](/docs/x) [ext](https://example.com/a.md) [anchor](#a) [tpl]({{docs}}/x)"},
+		{"[out](../../x.md)", "[out](../../x.md)"},
+		{"```\n[a](b.md)\n```\n[a](b.md)", "```\n[a](b.md)\n```\n[a](../partials/b.md)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.md, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(rebaseLinks([]byte(tt.md), "partials/links.md", "guides/page.md")))
+		})
+	}
+
+	assert.Equal(t, "[a](b.md)", string(rebaseLinks([]byte("[a](b.md)"), "partials/a.md", "partials/b.md")))
+}
+
+func TestCodeFence(t *testing.T) {
+	assert.Equal(t, "```", codeFence("```go\n", ""))
+	assert.Equal(t, "~~~~", codeFence("  ~~~~\n", ""))
+	assert.Equal(t, "", codeFence("text\n", ""))
+	assert.Equal(t, "```", codeFence("text\n", "```"))
+	assert.Equal(t, "````", codeFence("```\n", "````"))
+	assert.Equal(t, "", codeFence("````\n", "```"))
+}
@@ -0,0 +1,785 @@
+package docweaver
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// dotGraph is a graph parsed from the DOT language. A subset of DOT is supported: graph, node and edge statements
+// (including edge chains and default attribute statements), graph attributes and subgraphs, whose statements are
+// merged into the graph. Ports and HTML labels are not supported.
+type dotGraph struct {
+	directed bool
+	attrs    map[string]string
+	nodes    []*dotNode
+	edges    []*dotEdge
+	byId     map[string]*dotNode
+}
+
+type dotNode struct {
+	id    string
+	attrs map[string]string
+	// layout
+	rank, order   int
+	x, y          float64
+	width, height float64
+	virtual       bool
+}
+
+type dotEdge struct {
+	from, to *dotNode
+	attrs    map[string]string
+	points   [][2]float64 // route from the tail to the head node's border
+}
+
+// dotParser is a recursive descent parser of DOT source.
+type dotParser struct {
+	tokens []string
+	pos    int
+	graph  *dotGraph
+}
+
+// layoutEdge is an edge of the layered layout, between adjacent ranks.
+type layoutEdge struct {
+	from, to *dotNode
+}
+
+const (
+	dotFontSize     = 14.0
+	dotCharWidth    = dotFontSize * 0.6
+	dotNodeHeight   = 36.0
+	dotNodeMinWidth = 54.0
+	dotNodePadding  = 24.0
+	dotNodeSep      = 24.0
+	dotRankSep      = 48.0
+	dotMargin       = 8.0
+	dotArrowSize    = 9.0
+	dotOrderSweeps  = 8
+)
+
+// parseDot parses the DOT source of a graph.
+func parseDot(source string) (*dotGraph, error) {
+	tokens, err := dotTokens(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotParser{tokens: tokens, graph: &dotGraph{attrs: map[string]string{}, byId: map[string]*dotNode{}}}
+	if err := p.parseGraph(); err != nil {
+		return nil, err
+	}
+
+	return p.graph, nil
+}
+
+// dotTokens splits DOT source into tokens: punctuation, edge operators, IDs and quoted strings (kept quoted).
+func dotTokens(source string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\n' || c == '\r' || c == ' ' || c == '\t':
+			i++
+		case c == '#' && (i == 0 || source[i-1] == '\n'), strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, simpleError{"Unclosed comment."}
+			}
+			i += end + 4
+		case strings.HasPrefix(source[i:], "->") || strings.HasPrefix(source[i:], "--"):
+			tokens = append(tokens, source[i:i+2])
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(source) && source[j] != '"'; j++ {
+				if source[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(source) {
+				return nil, simpleError{"Unclosed string."}
+			}
+			tokens = append(tokens, source[i:j+1])
+			i = j + 1
+		case c == '<':
+			return nil, simpleError{"HTML labels are not supported."}
+		default:
+			j := i
+			for j < len(source) {
+				r, size := utf8.DecodeRuneInString(source[j:])
+				if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || (r == '-' && !strings.HasPrefix(source[j:], "->") && !strings.HasPrefix(source[j:], "--"))) {
+					break
+				}
+				j += size
+			}
+			if j == i {
+				return nil, simpleError{fmt.Sprintf("Unexpected character `%c`.", c)}
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+func (p *dotParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *dotParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *dotParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return simpleError{fmt.Sprintf("Expected `%s` but found `%s`.", token, t)}
+	}
+	return nil
+}
+
+func (p *dotParser) parseGraph() error {
+	if strings.EqualFold(p.peek(), "strict") {
+		p.next()
+	}
+	switch strings.ToLower(p.next()) {
+	case "digraph":
+		p.graph.directed = true
+	case "graph":
+	default:
+		return simpleError{"Expected `graph` or `digraph`."}
+	}
+	if p.peek() != "{" {
+		p.next()
+	}
+	if err := p.parseBlock(map[string]string{}, map[string]string{}); err != nil {
+		return err
+	}
+	if p.pos < len(p.tokens) {
+		return simpleError{fmt.Sprintf("Unexpected `%s` after graph.", p.peek())}
+	}
+
+	return nil
+}
+
+// parseBlock parses a `{ ... }` statement list with the given node and edge attribute defaults.
+func (p *dotParser) parseBlock(nodeDefaults, edgeDefaults map[string]string) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	nodeDefaults, edgeDefaults = copyAttrs(nodeDefaults), copyAttrs(edgeDefaults)
+
+	for {
+		switch t := p.peek(); {
+		case t == "":
+			return simpleError{"Expected `}`."}
+		case t == "}":
+			p.next()
+			return nil
+		case t == ";" || t == ",":
+			p.next()
+		case strings.EqualFold(t, "subgraph") || t == "{":
+			if strings.EqualFold(t, "subgraph") {
+				p.next()
+				if p.peek() != "{" {
+					p.next()
+				}
+			}
+			if err := p.parseBlock(nodeDefaults, edgeDefaults); err != nil {
+				return err
+			}
+		case strings.EqualFold(t, "node") || strings.EqualFold(t, "edge") || strings.EqualFold(t, "graph"):
+			p.next()
+			attrs, err := p.parseAttrs()
+			if err != nil {
+				return err
+			}
+			target := map[string]map[string]string{"node": nodeDefaults, "edge": edgeDefaults, "graph": p.graph.attrs}[strings.ToLower(t)]
+			for k, v := range attrs {
+				target[k] = v
+			}
+		default:
+			if err := p.parseStatement(nodeDefaults, edgeDefaults); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseStatement parses a graph attribute (`a=b`), node or edge statement.
+func (p *dotParser) parseStatement(nodeDefaults, edgeDefaults map[string]string) error {
+	id, err := p.parseId()
+	if err != nil {
+		return err
+	}
+	if p.peek() == "=" {
+		p.next()
+		value, err := p.parseId()
+		if err != nil {
+			return err
+		}
+		p.graph.attrs[id] = value
+		return nil
+	}
+	if p.peek() == ":" {
+		return simpleError{"Ports are not supported."}
+	}
+
+	ids := []string{id}
+	for p.peek() == "->" || p.peek() == "--" {
+		if op := p.next(); (op == "->") != p.graph.directed {
+			return simpleError{fmt.Sprintf("Edge operator `%s` does not match the graph type.", op)}
+		}
+		id, err := p.parseId()
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	attrs, err := p.parseAttrs()
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 1 {
+		n := p.graph.node(id, nodeDefaults)
+		for k, v := range attrs {
+			n.attrs[k] = v
+		}
+		return nil
+	}
+	for i := 1; i < len(ids); i++ {
+		edgeAttrs := copyAttrs(edgeDefaults)
+		for k, v := range attrs {
+			edgeAttrs[k] = v
+		}
+		p.graph.edges = append(p.graph.edges, &dotEdge{
+			from: p.graph.node(ids[i-1], nodeDefaults), to: p.graph.node(ids[i], nodeDefaults), attrs: edgeAttrs,
+		})
+	}
+
+	return nil
+}
+
+// parseAttrs parses any number of attribute lists (`[a=b, c=d][e=f]`).
+func (p *dotParser) parseAttrs() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.peek() == "[" {
+		p.next()
+		for p.peek() != "]" {
+			if p.peek() == ";" || p.peek() == "," {
+				p.next()
+				continue
+			}
+			key, err := p.parseId()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			if attrs[key], err = p.parseId(); err != nil {
+				return nil, err
+			}
+		}
+		p.next()
+	}
+
+	return attrs, nil
+}
+
+func (p *dotParser) parseId() (string, error) {
+	t := p.next()
+	switch {
+	case t == "" || strings.ContainsAny(t[:1], "{}[];,=:") || t == "->" || t == "--":
+		return "", simpleError{fmt.Sprintf("Expected an ID but found `%s`.", t)}
+	case strings.HasPrefix(t, "\""):
+		return strings.NewReplacer(`\"`, `"`, "\\\n", "", `\n`, "\n", `\l`, "\n", `\r`, "\n").Replace(t[1 : len(t)-1]), nil
+	}
+	return t, nil
+}
+
+// node returns the node with the given ID, adding it with the given default attributes if it does not exist.
+func (g *dotGraph) node(id string, defaults map[string]string) *dotNode {
+	if n, ok := g.byId[id]; ok {
+		return n
+	}
+	n := &dotNode{id: id, attrs: copyAttrs(defaults)}
+	g.byId[id] = n
+	g.nodes = append(g.nodes, n)
+	return n
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	c := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		c[k] = v
+	}
+	return c
+}
+
+func (n *dotNode) label() string {
+	if label, ok := n.attrs["label"]; ok {
+		return strings.ReplaceAll(label, `\N`, n.id)
+	}
+	return n.id
+}
+
+func (n *dotNode) shape() string {
+	switch shape := strings.ToLower(n.attrs["shape"]); shape {
+	case "box", "rect", "rectangle", "square":
+		return "box"
+	case "circle", "diamond", "plaintext", "plain", "none", "point":
+		return shape
+	}
+	return "ellipse"
+}
+
+// dotToSvg lays out the graph of the given DOT source and renders it as SVG.
+func dotToSvg(source string) (string, error) {
+	g, err := parseDot(source)
+	if err != nil {
+		return "", err
+	}
+	if len(g.nodes) == 0 {
+		return "", simpleError{"The graph has no nodes."}
+	}
+
+	width, height := g.layout()
+	return g.svg(width, height), nil
+}
+
+// layout positions nodes and routes edges using a layered (Sugiyama style) layout, returning the size of the drawing.
+// Ranks are assigned by longest path after reversing edges closing cycles, and nodes are ordered within ranks by
+// barycenter sweeps.
+func (g *dotGraph) layout() (width, height float64) {
+	rankDir := strings.ToUpper(g.attrs["rankdir"])
+	horizontal := rankDir == "LR" || rankDir == "RL"
+	for _, n := range g.nodes {
+		n.width = math.Max(dotNodeMinWidth, float64(maxLineLength(n.label()))*dotCharWidth+dotNodePadding)
+		n.height = dotNodeHeight * float64(len(strings.Split(n.label(), "\n")))
+		switch n.shape() {
+		case "circle":
+			n.width = math.Max(n.width, n.height)
+			n.height = n.width
+		case "diamond":
+			n.width, n.height = n.width*1.5, n.height*1.5
+		case "point":
+			n.width, n.height = 8, 8
+		}
+		if horizontal {
+			n.width, n.height = n.height, n.width
+		}
+	}
+
+	g.assignRanks()
+	ranks, edges, virtual := g.layers()
+	orderRanks(ranks, edges)
+
+	// place ranks, centering each on the widest
+	var rankWidths []float64
+	for _, rank := range ranks {
+		w := 0.0
+		for i, n := range rank {
+			if i > 0 {
+				w += dotNodeSep
+			}
+			w += n.width
+		}
+		rankWidths = append(rankWidths, w)
+		width = math.Max(width, w)
+	}
+	y := dotMargin
+	for r, rank := range ranks {
+		rankHeight := 0.0
+		for _, n := range rank {
+			rankHeight = math.Max(rankHeight, n.height)
+		}
+		x := dotMargin + (width-rankWidths[r])/2
+		for _, n := range rank {
+			n.x, n.y = x+n.width/2, y+rankHeight/2
+			x += n.width + dotNodeSep
+		}
+		y += rankHeight + dotRankSep
+	}
+	width, height = width+2*dotMargin, y-dotRankSep+dotMargin
+
+	g.routeEdges(virtual)
+
+	// rotate or flip for other rank directions
+	transform := func(x, y float64) (float64, float64) {
+		switch rankDir {
+		case "LR":
+			return y, x
+		case "RL":
+			return height - y, x
+		case "BT":
+			return x, height - y
+		}
+		return x, y
+	}
+	for _, rank := range ranks {
+		for _, n := range rank {
+			n.x, n.y = transform(n.x, n.y)
+			if horizontal {
+				n.width, n.height = n.height, n.width
+			}
+		}
+	}
+	for _, e := range g.edges {
+		for i, pt := range e.points {
+			e.points[i][0], e.points[i][1] = transform(pt[0], pt[1])
+		}
+	}
+	if horizontal {
+		width, height = height, width
+	}
+
+	return width, height
+}
+
+// assignRanks assigns each node the length of the longest path leading to it, ignoring edges closing cycles.
+func (g *dotGraph) assignRanks() {
+	forward := g.acyclicEdges()
+	incoming := map[*dotNode][]*dotNode{}
+	for _, e := range forward {
+		incoming[e.to] = append(incoming[e.to], e.from)
+	}
+
+	done := map[*dotNode]bool{}
+	var rank func(n *dotNode) int
+	rank = func(n *dotNode) int {
+		if done[n] {
+			return n.rank
+		}
+		done[n] = true
+		n.rank = 0
+		for _, from := range incoming[n] {
+			if r := rank(from) + 1; r > n.rank {
+				n.rank = r
+			}
+		}
+		return n.rank
+	}
+	for _, n := range g.nodes {
+		rank(n)
+	}
+}
+
+// acyclicEdges returns the edges of the graph as layout edges, reversing those closing cycles and omitting loops.
+func (g *dotGraph) acyclicEdges() []layoutEdge {
+	outgoing := map[*dotNode][]*dotEdge{}
+	for _, e := range g.edges {
+		outgoing[e.from] = append(outgoing[e.from], e)
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[*dotNode]int{}
+	var edges []layoutEdge
+	var visit func(n *dotNode)
+	visit = func(n *dotNode) {
+		state[n] = visiting
+		for _, e := range outgoing[n] {
+			switch {
+			case e.to == e.from:
+			case state[e.to] == visiting:
+				edges = append(edges, layoutEdge{from: e.to, to: e.from})
+			default:
+				edges = append(edges, layoutEdge{from: e.from, to: e.to})
+				if state[e.to] == 0 {
+					visit(e.to)
+				}
+			}
+		}
+		state[n] = visited
+	}
+	for _, n := range g.nodes {
+		if state[n] == 0 {
+			visit(n)
+		}
+	}
+
+	return edges
+}
+
+// layers returns the nodes of each rank, adding virtual nodes where edges span several ranks, the edges between
+// adjacent ranks and the virtual nodes of each edge, from the lower to the higher rank.
+func (g *dotGraph) layers() ([][]*dotNode, []layoutEdge, map[*dotEdge][]*dotNode) {
+	var ranks [][]*dotNode
+	virtual := map[*dotEdge][]*dotNode{}
+	add := func(n *dotNode) {
+		for len(ranks) <= n.rank {
+			ranks = append(ranks, nil)
+		}
+		n.order = len(ranks[n.rank])
+		ranks[n.rank] = append(ranks[n.rank], n)
+	}
+	for _, n := range g.nodes {
+		add(n)
+	}
+
+	var edges []layoutEdge
+	for _, e := range g.edges {
+		from, to := e.from, e.to
+		if from.rank > to.rank {
+			from, to = to, from
+		}
+		for r := from.rank + 1; r < to.rank; r++ {
+			v := &dotNode{rank: r, virtual: true}
+			add(v)
+			virtual[e] = append(virtual[e], v)
+			edges = append(edges, layoutEdge{from: from, to: v})
+			from = v
+		}
+		if from.rank != to.rank {
+			edges = append(edges, layoutEdge{from: from, to: to})
+		}
+	}
+
+	return ranks, edges, virtual
+}
+
+// orderRanks orders the nodes of each rank by the barycenter of their neighbours in the previous (downward sweeps) or
+// next (upward sweeps) rank, reducing edge crossings.
+func orderRanks(ranks [][]*dotNode, edges []layoutEdge) {
+	up, down := map[*dotNode][]*dotNode{}, map[*dotNode][]*dotNode{}
+	for _, e := range edges {
+		up[e.to] = append(up[e.to], e.from)
+		down[e.from] = append(down[e.from], e.to)
+	}
+
+	sortRank := func(rank []*dotNode, neighbours map[*dotNode][]*dotNode) {
+		barycenter := map[*dotNode]float64{}
+		for _, n := range rank {
+			barycenter[n] = float64(n.order)
+			if len(neighbours[n]) > 0 {
+				sum := 0.0
+				for _, m := range neighbours[n] {
+					sum += float64(m.order)
+				}
+				barycenter[n] = sum / float64(len(neighbours[n]))
+			}
+		}
+		sort.SliceStable(rank, func(i, j int) bool { return barycenter[rank[i]] < barycenter[rank[j]] })
+		for i, n := range rank {
+			n.order = i
+		}
+	}
+
+	for sweep := 0; sweep < dotOrderSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for r := 1; r < len(ranks); r++ {
+				sortRank(ranks[r], up)
+			}
+		} else {
+			for r := len(ranks) - 2; r >= 0; r-- {
+				sortRank(ranks[r], down)
+			}
+		}
+	}
+}
+
+// routeEdges routes each edge through its virtual nodes, clipping the ends at the node borders.
+func (g *dotGraph) routeEdges(virtual map[*dotEdge][]*dotNode) {
+	for _, e := range g.edges {
+		if e.from == e.to {
+			x, y := e.from.x+e.from.width/2, e.from.y
+			e.points = [][2]float64{{x - 4, y - 8}, {x + 18, y - 14}, {x + 18, y + 14}, {x - 1, y + 9}}
+			continue
+		}
+
+		points := [][2]float64{{e.from.x, e.from.y}}
+		vs := virtual[e]
+		for i := range vs {
+			v := vs[i]
+			if e.to.rank < e.from.rank {
+				v = vs[len(vs)-1-i]
+			}
+			points = append(points, [2]float64{v.x, v.y})
+		}
+		points = append(points, [2]float64{e.to.x, e.to.y})
+
+		points[0] = clipToNode(e.from, points[1])
+		points[len(points)-1] = clipToNode(e.to, points[len(points)-2])
+		e.points = points
+	}
+}
+
+// clipToNode returns the point where the line from the node's center towards [toward] leaves the node's shape.
+func clipToNode(n *dotNode, toward [2]float64) [2]float64 {
+	dx, dy := toward[0]-n.x, toward[1]-n.y
+	if dx == 0 && dy == 0 {
+		return [2]float64{n.x, n.y}
+	}
+	w, h := n.width/2, n.height/2
+
+	var t float64
+	switch n.shape() {
+	case "box", "plaintext", "plain", "none":
+		t = math.Min(math.Abs(w/nonZero(dx)), math.Abs(h/nonZero(dy)))
+	case "diamond":
+		t = 1 / (math.Abs(dx)/w + math.Abs(dy)/h)
+	default:
+		t = 1 / math.Sqrt(dx*dx/(w*w)+dy*dy/(h*h))
+	}
+
+	return [2]float64{n.x + dx*t, n.y + dy*t}
+}
+
+func nonZero(v float64) float64 {
+	if v == 0 {
+		return 1e-9
+	}
+	return v
+}
+
+func maxLineLength(label string) (max int) {
+	for _, line := range strings.Split(label, "\n") {
+		if n := utf8.RuneCountInString(line); n > max {
+			max = n
+		}
+	}
+	return
+}
+
+// svg renders the laid out graph.
+func (g *dotGraph) svg(width, height float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" role="img" width="%s" height="%s" viewBox="0 0 %s %s">`,
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height))
+	if label := g.attrs["label"]; label != "" {
+		fmt.Fprintf(&b, "<title>%s</title>", html.EscapeString(label))
+	}
+	fmt.Fprintf(&b, `<g font-family="sans-serif" font-size="%s" fill="none" stroke="black">`, svgNum(dotFontSize))
+
+	for _, e := range g.edges {
+		b.WriteString(g.svgEdge(e))
+	}
+	for _, n := range g.nodes {
+		b.WriteString(svgNode(n))
+	}
+
+	b.WriteString("</g></svg>")
+	return b.String()
+}
+
+func (g *dotGraph) svgEdge(e *dotEdge) string {
+	if len(e.points) < 2 {
+		return ""
+	}
+	color := attrOrDefault(e.attrs, "color", "black")
+	points := e.points
+	var b strings.Builder
+
+	head := g.directed && !strings.EqualFold(e.attrs["dir"], "none") && !strings.EqualFold(e.attrs["arrowhead"], "none")
+	if head {
+		// shorten the line so it ends at the arrow's base
+		last, prev := points[len(points)-1], points[len(points)-2]
+		dx, dy := last[0]-prev[0], last[1]-prev[1]
+		length := math.Hypot(dx, dy)
+		if length > 0 {
+			ux, uy := dx/length, dy/length
+			base := [2]float64{last[0] - ux*dotArrowSize, last[1] - uy*dotArrowSize}
+			points = append(append([][2]float64{}, points[:len(points)-1]...), base)
+			fmt.Fprintf(&b, `<polygon points="%s,%s %s,%s %s,%s" fill="%s" stroke="%s"/>`,
+				svgNum(last[0]), svgNum(last[1]),
+				svgNum(base[0]-uy*dotArrowSize/2), svgNum(base[1]+ux*dotArrowSize/2),
+				svgNum(base[0]+uy*dotArrowSize/2), svgNum(base[1]-ux*dotArrowSize/2),
+				html.EscapeString(color), html.EscapeString(color))
+		}
+	}
+
+	var coords []string
+	for _, pt := range points {
+		coords = append(coords, svgNum(pt[0])+","+svgNum(pt[1]))
+	}
+	dash := ""
+	if strings.EqualFold(e.attrs["style"], "dashed") {
+		dash = ` stroke-dasharray="5,3"`
+	} else if strings.EqualFold(e.attrs["style"], "dotted") {
+		dash = ` stroke-dasharray="1,3"`
+	}
+	line := fmt.Sprintf(`<polyline points="%s" stroke="%s"%s/>`, strings.Join(coords, " "), html.EscapeString(color), dash)
+
+	if label := e.attrs["label"]; label != "" {
+		mid := len(points) / 2
+		x, y := (points[mid-1][0]+points[mid][0])/2, (points[mid-1][1]+points[mid][1])/2
+		line += svgText(x+4, y, label, "start", attrOrDefault(e.attrs, "fontcolor", "black"))
+	}
+
+	return line + b.String()
+}
+
+func svgNode(n *dotNode) string {
+	color := attrOrDefault(n.attrs, "color", "black")
+	fill := "none"
+	if strings.Contains(strings.ToLower(n.attrs["style"]), "filled") {
+		fill = attrOrDefault(n.attrs, "fillcolor", attrOrDefault(n.attrs, "color", "lightgrey"))
+	}
+	paint := fmt.Sprintf(` fill="%s" stroke="%s"`, html.EscapeString(fill), html.EscapeString(color))
+	w, h := n.width/2, n.height/2
+
+	var shape string
+	switch n.shape() {
+	case "box":
+		shape = fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s"%s/>`, svgNum(n.x-w), svgNum(n.y-h), svgNum(n.width), svgNum(n.height), paint)
+	case "diamond":
+		shape = fmt.Sprintf(`<polygon points="%s,%s %s,%s %s,%s %s,%s"%s/>`,
+			svgNum(n.x), svgNum(n.y-h), svgNum(n.x+w), svgNum(n.y), svgNum(n.x), svgNum(n.y+h), svgNum(n.x-w), svgNum(n.y), paint)
+	case "point":
+		return fmt.Sprintf(`<circle cx="%s" cy="%s" r="4" fill="%s" stroke="%s"/>`, svgNum(n.x), svgNum(n.y), html.EscapeString(color), html.EscapeString(color))
+	case "plaintext", "plain", "none":
+	default:
+		shape = fmt.Sprintf(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`, svgNum(n.x), svgNum(n.y), svgNum(w), svgNum(h), paint)
+	}
+
+	return shape + svgText(n.x, n.y, n.label(), "middle", attrOrDefault(n.attrs, "fontcolor", "black"))
+}
+
+// svgText renders a (multi-line) label vertically centered on y.
+func svgText(x, y float64, label, anchor, color string) string {
+	lines := strings.Split(label, "\n")
+	lineHeight := dotFontSize * 1.2
+	top := y - lineHeight*float64(len(lines)-1)/2
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<text text-anchor="%s" dominant-baseline="central" fill="%s" stroke="none">`, anchor, html.EscapeString(color))
+	for i, line := range lines {
+		fmt.Fprintf(&b, `<tspan x="%s" y="%s">%s</tspan>`, svgNum(x), svgNum(top+lineHeight*float64(i)), html.EscapeString(line))
+	}
+	b.WriteString("</text>")
+
+	return b.String()
+}
+
+func attrOrDefault(attrs map[string]string, key, defaultValue string) string {
+	if v := attrs[key]; v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// svgNum formats a coordinate with at most one decimal.
+func svgNum(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.1f", v), "0"), ".")
+}
@@ -0,0 +1,107 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseDot(t *testing.T) {
+	g, err := parseDot(`
+/* release pipeline */
+strict digraph "release" {
+  rankdir = LR; // left to right
+  node [shape=box];
+  build [label="Build\nstep"];
+  build -> test -> deploy [color=green];
+  subgraph cluster_ops { edge [style=dashed]; deploy -> monitor; }
+  monitor [shape=ellipse]
+}`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, g.directed)
+	assert.Equal(t, "LR", g.attrs["rankdir"])
+	var ids []string
+	for _, n := range g.nodes {
+		ids = append(ids, n.id)
+	}
+	assert.Equal(t, []string{"build", "test", "deploy", "monitor"}, ids)
+	assert.Equal(t, "Build\nstep", g.byId["build"].label())
+	assert.Equal(t, "box", g.byId["test"].shape())
+	assert.Equal(t, "ellipse", g.byId["monitor"].shape())
+	if assert.Len(t, g.edges, 3) {
+		assert.Equal(t, "green", g.edges[1].attrs["color"])
+		assert.Equal(t, "dashed", g.edges[2].attrs["style"])
+		assert.Empty(t, g.edges[0].attrs["style"])
+	}
+}
+
+func TestParseDot_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no graph":       `a -> b`,
+		"unclosed":       `digraph { a -> b`,
+		"wrong operator": `graph { a -> b }`,
+		"ports":          `digraph { a:n -> b }`,
+		"html label":     `digraph { a [label=<b>x</b>] }`,
+		"trailing":       `digraph { a } b`,
+	}
+
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseDot(source)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDotGraph_Layout(t *testing.T) {
+	g, err := parseDot(`digraph { a -> b; a -> c; b -> d; c -> d; d -> a; a -> d }`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	width, height := g.layout()
+
+	a, b, c, d := g.byId["a"], g.byId["b"], g.byId["c"], g.byId["d"]
+	assert.Equal(t, []int{0, 1, 1, 2}, []int{a.rank, b.rank, c.rank, d.rank})
+	assert.Less(t, a.y, b.y)
+	assert.Equal(t, b.y, c.y)
+	assert.NotEqual(t, b.x, c.x)
+	assert.Less(t, b.y, d.y)
+	assert.LessOrEqual(t, d.y+d.height/2, height)
+	assert.LessOrEqual(t, c.x+c.width/2, width)
+	for _, e := range g.edges {
+		assert.GreaterOrEqual(t, len(e.points), 2)
+	}
+	// a -> d spans two ranks and is routed through a virtual node
+	assert.Len(t, g.edges[5].points, 3)
+
+	t.Run("left to right", func(t *testing.T) {
+		g, _ := parseDot(`digraph { rankdir=LR; a -> b }`)
+		g.layout()
+
+		assert.Less(t, g.byId["a"].x, g.byId["b"].x)
+		assert.Equal(t, g.byId["a"].y, g.byId["b"].y)
+	})
+}
+
+func TestDotToSvg(t *testing.T) {
+	svg, err := dotToSvg(`digraph { label="Flow"; a [label="<A & B>", shape=box, style=filled, fillcolor=lightblue]; a -> b [label="go"]; b [shape=diamond] }`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" role="img"`))
+	assert.Contains(t, svg, "<title>Flow</title>")
+	assert.Contains(t, svg, `fill="lightblue"`)
+	assert.Contains(t, svg, "&lt;A &amp; B&gt;")
+	assert.Contains(t, svg, ">go</tspan>")
+	assert.Equal(t, 1, strings.Count(svg, "<rect"))
+	assert.Equal(t, 2, strings.Count(svg, "<polygon")) // diamond and arrowhead
+
+	_, err = dotToSvg(`digraph {}`)
+	assert.Error(t, err)
+}
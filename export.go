@@ -0,0 +1,390 @@
+package docweaver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cp "github.com/otiai10/copy"
+)
+
+// ExportOptions configures a static export.
+type ExportOptions struct {
+	OutDir      string // directory exported files are written to
+	TemplateDir string // directory of html/template layouts (*.gohtml, *.html), built-in templates are used if empty
+	Incremental bool   // only re-render versions changed since the previous export
+	// PrerenderDiagrams lays out graphviz (DOT) diagrams as inline SVG, so exported pages need no JavaScript for them.
+	PrerenderDiagrams bool
+}
+
+// Exporter exports all products, versions and pages to static HTML files.
+type Exporter interface {
+	Export() error
+}
+
+type exporter struct {
+	repo              ProductRepository
+	options           ExportOptions
+	templates         *template.Template
+	templatesChecksum string
+}
+
+// exportManifest records the state of exported versions, enabling incremental exports.
+type exportManifest struct {
+	Templates string            `json:"templates"`
+	Site      string            `json:"site"`     // fingerprint of inputs shared by all versions, see siteFingerprint
+	Options   string            `json:"options"`  // fingerprint of the options and configuration exported pages depend on
+	Versions  map[string]string `json:"versions"` // product key/version -> version fingerprint
+}
+
+const (
+	exportManifestFileName = ".docweaver-export.json"
+	exportIndexFileName    = "index.html"
+	imgDirName             = "images"
+)
+
+var redirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Redirecting…</title>
+<link rel="canonical" href="{{.}}">
+<meta http-equiv="refresh" content="0; url={{.}}">
+</head>
+<body><a href="{{.}}">{{.}}</a></body>
+</html>
+`))
+
+// GetExporter returns an Exporter for the products of [repo]. Templates are loaded from the template directory given
+// in [options]; pages are rendered with the "page" template (or their layout) and the product listing with the
+// "products" template. Built-in templates are used for those not provided.
+func GetExporter(repo ProductRepository, options ExportOptions) (Exporter, error) {
+	if repo == nil {
+		repo = GetRepository("")
+	}
+	if options.OutDir == "" {
+		return nil, simpleError{"No export output directory provided."}
+	}
+
+	e := &exporter{repo: repo, options: options}
+	if options.TemplateDir != "" {
+		if err := e.loadTemplates(); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+func (e *exporter) loadTemplates() error {
+	var files []string
+	for _, pattern := range []string{"*.gohtml", "*.html"} {
+		matches, err := filepath.Glob(filepath.Join(e.options.TemplateDir, pattern))
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return simpleError{fmt.Sprintf("No templates found in template directory `%s`.", e.options.TemplateDir)}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s:%d:", filepath.Base(f), len(b))
+		h.Write(b)
+	}
+
+	templates, err := template.New("").Funcs(TemplateFuncs).ParseFiles(files...)
+	if err != nil {
+		return simpleError{fmt.Sprintf("Failed to parse templates in `%s`. %s", e.options.TemplateDir, err)}
+	}
+	e.templates = templates
+	e.templatesChecksum = hex.EncodeToString(h.Sum(nil))
+
+	return nil
+}
+
+// Export renders all pages of all product versions. Incremental exports skip versions whose files did not change
+// since the previous export, unless the templates, export options or inputs shared by all versions changed. Output of versions no
+// longer present is removed.
+func (e *exporter) Export() error {
+	previous := e.readManifest()
+	manifest := &exportManifest{Templates: e.templatesChecksum, Options: e.options.fingerprint(), Versions: map[string]string{}}
+
+	products, err := e.repo.FindAllProducts()
+	if err != nil {
+		return err
+	}
+	if manifest.Site, err = e.siteFingerprint(products); err != nil {
+		return err
+	}
+	unchanged := e.options.Incremental && previous.Templates == manifest.Templates && previous.Site == manifest.Site &&
+		previous.Options == manifest.Options
+
+	if err := e.renderFile(normalizeRoutePrefix(GetRoutePrefix()), productsTemplateName, products); err != nil {
+		return err
+	}
+
+	for i := range products {
+		product := &products[i]
+		target := fmt.Sprintf("%s/%s", product.BaseUrl, product.currentVersion())
+		if err := e.writeRedirect(product.BaseUrl, target); err != nil {
+			return err
+		}
+
+		for _, version := range product.Versions {
+			key := fmt.Sprintf("%s/%s", product.Key(), version)
+			fingerprint, err := product.root.versionFingerprint(version)
+			if err != nil {
+				return err
+			}
+			manifest.Versions[key] = fingerprint
+
+			if unchanged && previous.Versions[key] == fingerprint {
+				log(lInfo, "Version `%s` of product `%s` is unchanged. Skipped export.\n", version, product.Key())
+				continue
+			}
+			if err := e.exportVersion(product, version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := e.writeHighlightCss(products); err != nil {
+		return err
+	}
+
+	if err := e.writeFeeds(normalizeRoutePrefix(GetRoutePrefix()), ""); err != nil {
+		return err
+	}
+	for _, product := range products {
+		if err := e.writeFeeds(product.BaseUrl, product.Key()); err != nil {
+			return err
+		}
+	}
+
+	sitemapFiles, err := GenerateSitemap(e.repo)
+	if err != nil {
+		return err
+	}
+	for name, content := range sitemapFiles {
+		if err := writeFile(filepath.Join(e.options.OutDir, name), content); err != nil {
+			return err
+		}
+	}
+
+	for key := range previous.Versions {
+		if _, ok := manifest.Versions[key]; !ok {
+			log(lInfo, "Removing exported version `%s`.\n", key)
+			_ = removeDir(e.outPath(fmt.Sprintf("%s/%s", normalizeRoutePrefix(GetRoutePrefix()), key)))
+		}
+	}
+
+	return e.writeManifest(manifest)
+}
+
+// fingerprint returns a hash of the options and configuration affecting the content of exported pages, so that
+// changing them forces a full export.
+func (o ExportOptions) fingerprint() string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("prerender-diagrams=%t|%s", o.PrerenderDiagrams, exportConfig())))
+	return hex.EncodeToString(h[:])
+}
+
+// exportConfig returns the configuration every exported page depends on: URLs, sanitization, table of contents levels
+// and the URLs of scripts pages load.
+func exportConfig() string {
+	return fmt.Sprintf("%s|%s|%s|%t|%s|%d|%d|%s|%s|%s", GetRoutePrefix(), GetAssetsRoutePrefix(), GetSiteUrl(), GetSanitize(),
+		strings.Join(GetSanitizeExempt(), ","), GetTocMinLevel(), GetTocMaxLevel(), GetMathAssetsUrl(), GetMermaidUrl(),
+		GetVizUrl())
+}
+
+// siteFingerprint returns a hash of the inputs pages depend on beyond the files of their own version: the versions,
+// latest version and meta file of each product, and the pages of each version, which docweaver links are resolved
+// against.
+func (e *exporter) siteFingerprint(products []Product) (string, error) {
+	h := sha256.New()
+	for i := range products {
+		product := &products[i]
+		meta, err := json.Marshal(product.meta)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s:%s:%s:%s\n", product.Key(), strings.Join(product.Versions, ","), product.currentVersion(), meta)
+
+		for _, version := range product.Versions {
+			pagePaths, err := listPages(e.repo, product.Key(), version)
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "%s:%s\n", version, strings.Join(pagePaths, ","))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (e *exporter) exportVersion(product *Product, version string) error {
+	log(lInfo, "Exporting version `%s` of product `%s`.\n", version, product.Key())
+	versionUrl := fmt.Sprintf("%s/%s", product.BaseUrl, version)
+	_ = removeDir(e.outPath(versionUrl))
+
+	pagePaths, err := listPages(e.repo, product.Key(), version)
+	if err != nil {
+		return err
+	}
+
+	for _, pagePath := range pagePaths {
+		page, err := e.repo.GetPage(product.Key(), version, pagePath)
+		if err != nil {
+			log(lWarn, "Failed to export page `%s` of product `%s`, version `%s`. %s\n", pagePath, product.Key(), version, err)
+			continue
+		}
+		if page.Draft {
+			continue
+		}
+		if e.options.PrerenderDiagrams {
+			page.Content = prerenderDiagrams(page.Content)
+		}
+
+		if err := e.renderFile(page.Url(), pageLayout(e.templates, page), page); err != nil {
+			return err
+		}
+		for _, alias := range page.Aliases {
+			if err := validatePagePath(alias); err != nil {
+				log(lWarn, "Skipped alias of page `%s`. %s\n", page.Url(), err)
+				continue
+			}
+			if err := e.writeRedirect(fmt.Sprintf("%s/%s", versionUrl, alias), page.Url()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := e.writeRedirect(versionUrl, fmt.Sprintf("%s/%s", versionUrl, defaultPagePath)); err != nil {
+		return err
+	}
+
+	imgSrcDir := filepath.Join(product.root.versionFilePath(version), imgDirName)
+	if _, err := os.Stat(imgSrcDir); err == nil {
+		imgTargetDir := filepath.Join(e.outPath(normalizeRoutePrefix(GetAssetsRoutePrefix())), product.Key(), version, imgDirName)
+		if err := cp.Copy(imgSrcDir, imgTargetDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderFile renders the named template with the given data to the index file of the given URL path.
+func (e *exporter) renderFile(urlPath, name string, data interface{}) error {
+	var out bytes.Buffer
+	if err := lookupTemplate(e.templates, name).Execute(&out, data); err != nil {
+		return simpleError{fmt.Sprintf("Failed to render template `%s` for `%s`. %s", name, urlPath, err)}
+	}
+
+	return writeFile(filepath.Join(e.outPath(urlPath), exportIndexFileName), out.Bytes())
+}
+
+// writeRedirect writes a page redirecting from the given URL path to target.
+func (e *exporter) writeRedirect(urlPath, target string) error {
+	var out bytes.Buffer
+	if err := redirectTemplate.Execute(&out, target); err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(e.outPath(urlPath), exportIndexFileName), out.Bytes())
+}
+
+// writeFeeds writes the Atom and RSS feeds of the product with the given key (or of all products if the key is empty)
+// beneath the given URL path.
+func (e *exporter) writeFeeds(urlPath, productKey string) error {
+	feed, err := GetFeed(e.repo, productKey)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{atomFeedFileName, rssFeedFileName} {
+		content, err := feed.render(name)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(e.outPath(urlPath), name), content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeHighlightCss writes the syntax highlighting stylesheets of the themes used by the given products.
+func (e *exporter) writeHighlightCss(products []Product) error {
+	written := map[string]bool{}
+	for _, product := range products {
+		urlPath := product.HighlightCssUrl()
+		if urlPath == "" || written[urlPath] {
+			continue
+		}
+		written[urlPath] = true
+
+		css, err := HighlightCss(product.meta.Highlight.theme())
+		if err != nil {
+			log(lWarn, "Skipped highlight stylesheet of product `%s`. %s\n", product.Key(), err)
+			continue
+		}
+		if err := writeFile(e.outPath(urlPath), []byte(css)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outPath maps a URL path to its directory within the output directory.
+func (e *exporter) outPath(urlPath string) string {
+	return filepath.Join(e.options.OutDir, filepath.FromSlash(strings.Trim(urlPath, "/")))
+}
+
+func (e *exporter) readManifest() *exportManifest {
+	manifest := &exportManifest{Versions: map[string]string{}}
+	b, err := os.ReadFile(filepath.Join(e.options.OutDir, exportManifestFileName))
+	if err != nil {
+		return manifest
+	}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		log(lWarn, "Failed to read export manifest. A full export will be performed. %s\n", err)
+		return &exportManifest{Versions: map[string]string{}}
+	}
+
+	return manifest
+}
+
+func (e *exporter) writeManifest(manifest *exportManifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(e.options.OutDir, exportManifestFileName), b)
+}
+
+// writeFile writes data to the file at path, creating parent directories as needed.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,141 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	cp "github.com/otiai10/copy"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExporter_Export(t *testing.T) {
+	outDir, templateDir := t.TempDir(), t.TempDir()
+	pageTemplate := `{{define "page"}}<title>{{.Title}}</title>{{rawHtml .Content}}{{end}}`
+	if err := os.WriteFile(filepath.Join(templateDir, "page.gohtml"), []byte(pageTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	productDir := filepath.Join(outDir, "docs", testProductKey)
+	readFile := func(path ...string) string {
+		b, err := os.ReadFile(filepath.Join(append([]string{productDir}, path...)...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	export := func(incremental bool, prerender bool) {
+		exporter, err := docweaver.GetExporter(repo, docweaver.ExportOptions{
+			OutDir: outDir, TemplateDir: templateDir, Incremental: incremental, PrerenderDiagrams: prerender,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := exporter.Export(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	export(false, false)
+
+	assert.Contains(t, readFile("1.0", "installation", "index.html"), "<title>Product 1</title>")
+	assert.Contains(t, readFile("main", "guides", "advanced", "caching", "index.html"), "Advanced caching")
+	assert.Contains(t, readFile("index.html"), fmt.Sprintf("url=/docs/%s/1.0", testProductKey))
+	assert.Contains(t, readFile("main", "index.html"), fmt.Sprintf("url=/docs/%s/main/installation", testProductKey))
+	assert.Contains(t, readFile("main", "setup", "index.html"), fmt.Sprintf("url=/docs/%s/main/configuration", testProductKey))
+	assert.FileExists(t, filepath.Join(outDir, "docs", "index.html"))
+	assert.FileExists(t, filepath.Join(outDir, "sitemap.xml"))
+	assert.FileExists(t, filepath.Join(outDir, "robots.txt"))
+	assert.FileExists(t, filepath.Join(outDir, "docs", "feed.atom"))
+	assert.FileExists(t, filepath.Join(productDir, "feed.rss"))
+	assert.FileExists(t, filepath.Join(outDir, filepath.FromSlash(docweaver.GetAssetsRoutePrefix()), "_highlight", "monokai.css"))
+	assert.NoFileExists(t, filepath.Join(productDir, "2.0-temp", "installation", "index.html"))
+	assert.Contains(t, readFile("main", "guides", "index.html"), `<div class="diagram graphviz">digraph release {`)
+
+	t.Run("incremental", func(t *testing.T) {
+		marker := filepath.Join(productDir, "1.0", "installation", "index.html")
+		if err := os.WriteFile(marker, []byte("marker"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		export(true, false)
+		assert.Equal(t, "marker", readFile("1.0", "installation", "index.html"))
+
+		// changed options affect all pages, so all versions are exported again
+		export(true, true)
+		assert.Contains(t, readFile("1.0", "installation", "index.html"), "<title>Product 1</title>")
+
+		export(false, false)
+		assert.Contains(t, readFile("1.0", "installation", "index.html"), "<title>Product 1</title>")
+	})
+
+	t.Run("prerender diagrams", func(t *testing.T) {
+		export(false, true)
+		guides := readFile("main", "guides", "index.html")
+
+		assert.Contains(t, guides, `<div class="diagram graphviz-svg"><svg xmlns="http://www.w3.org/2000/svg"`)
+		assert.Contains(t, guides, `<div class="diagram mermaid">sequenceDiagram`)
+		assert.NotContains(t, guides, `<div class="diagram graphviz">`)
+	})
+}
+
+func TestExporter_Export_IncrementalSiteChanges(t *testing.T) {
+	docs, outDir := t.TempDir(), t.TempDir()
+	if err := cp.Copy(filepath.Join(docsDir, testProductKey), filepath.Join(docs, testProductKey)); err != nil {
+		t.Fatal(err)
+	}
+	export := func() {
+		exporter, err := docweaver.GetExporter(docweaver.GetRepository(docs), docweaver.ExportOptions{OutDir: outDir, Incremental: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := exporter.Export(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	marker := filepath.Join(outDir, "docs", testProductKey, "1.0", "installation", "index.html")
+	writeMarker := func() {
+		if err := os.WriteFile(marker, []byte("marker"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	export()
+
+	writeMarker()
+	export()
+	assert.FileExists(t, marker)
+	if b, _ := os.ReadFile(marker); !assert.Equal(t, "marker", string(b)) {
+		return
+	}
+
+	// a page added to another version may be the target of links, so all versions are exported again
+	if err := os.WriteFile(filepath.Join(docs, testProductKey, "main", "faq.md"), []byte("# FAQ"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	export()
+	b, _ := os.ReadFile(marker)
+	assert.NotEqual(t, "marker", string(b))
+
+	writeMarker()
+	if err := os.WriteFile(filepath.Join(docs, testProductKey, "main", ".docweaver.yml"), []byte("name: Renamed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	export()
+	b, _ = os.ReadFile(marker)
+	assert.NotEqual(t, "marker", string(b))
+
+	// configuration affecting all pages forces a full export too
+	for key, value := range map[string]string{
+		docweaver.EnvKeyTocMaxLevel: "4",
+		docweaver.EnvKeySiteUrl:     "https://example.com",
+		docweaver.EnvKeyMermaidUrl:  "/assets/mermaid.esm.min.mjs",
+	} {
+		writeMarker()
+		t.Setenv(key, value)
+		export()
+		b, _ = os.ReadFile(marker)
+		assert.NotEqual(t, "marker", string(b), key)
+	}
+}
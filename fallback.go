@@ -0,0 +1,103 @@
+package docweaver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageNotFoundError is returned when a page does not exist in the requested version of a product. It carries the
+// versions of the product in which the page does exist.
+type PageNotFoundError struct {
+	ProductKey string
+	Version    string
+	PagePath   string
+	Versions   []string // versions holding the page, nearest to the requested version first
+	Fallback   string   // version the page is redirected to, set if the product's version fallback is "redirect"
+	err        error
+}
+
+// version fallback modes
+const (
+	// VersionFallbackSuggest reports the versions holding a missing page.
+	VersionFallbackSuggest string = "suggest"
+	// VersionFallbackRedirect redirects to a missing page in the latest version holding it, or in the nearest one if
+	// only main versions hold it.
+	VersionFallbackRedirect string = "redirect"
+)
+
+func (e *PageNotFoundError) Error() string {
+	msg := fmt.Sprintf("Page `%s` does not exist in version `%s` of product `%s`.", e.PagePath, e.Version, e.ProductKey)
+	if len(e.Versions) > 0 {
+		msg = fmt.Sprintf("%s It exists in: %s.", msg, strings.Join(e.Versions, ", "))
+	}
+	return msg
+}
+
+func (e *PageNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// PageUrl returns the URL of the missing page in the given version.
+func (e *PageNotFoundError) PageUrl(version string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", normalizeRoutePrefix(GetRoutePrefix()), e.ProductKey, version, e.PagePath)
+}
+
+// newPageNotFoundError returns a PageNotFoundError for a page missing in the given version of product [p], listing the
+// other versions holding the page.
+func newPageNotFoundError(p *Product, version, pagePath string, err error) *PageNotFoundError {
+	var versions []string
+	for _, v := range p.Versions {
+		if v == version {
+			continue
+		}
+		if _, err := confinePath(p.root.versionFilePath(v), p.root.pageFilePath(v, pagePath)); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	e := &PageNotFoundError{
+		ProductKey: p.Key(),
+		Version:    version,
+		PagePath:   pagePath,
+		Versions:   nearestVersions(version, versions),
+		err:        err,
+	}
+	if p.versionFallback() == VersionFallbackRedirect && len(e.Versions) > 0 {
+		e.Fallback = latestVersion(e.Versions)
+		if e.Fallback == versionNone || e.Fallback == "" {
+			e.Fallback = e.Versions[0]
+		}
+	}
+
+	return e
+}
+
+// nearestVersions orders versions by their distance to the given version in version order. Of equally distant
+// versions, the newer one comes first.
+func nearestVersions(version string, versions []string) []string {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	sorted := append([]string{version}, versions...)
+	sortVersions(sorted)
+	pos := 0
+	for i, v := range sorted {
+		if v == version {
+			pos = i
+			break
+		}
+	}
+
+	nearest := make([]string, 0, len(versions))
+	for d := 1; len(nearest) < len(versions); d++ {
+		if i := pos + d; i < len(sorted) {
+			nearest = append(nearest, sorted[i])
+		}
+		if i := pos - d; i >= 0 {
+			nearest = append(nearest, sorted[i])
+		}
+	}
+
+	return nearest
+}
@@ -0,0 +1,38 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNearestVersions(t *testing.T) {
+	tests := []struct {
+		version  string
+		versions []string
+		expected []string
+	}{
+		{"1.0", nil, nil},
+		{"1.0", []string{"0.9", "2.0", "main"}, []string{"2.0", "0.9", "main"}},
+		{"0.8", []string{"2.0", "0.9", "1.0"}, []string{"0.9", "1.0", "2.0"}},
+		{"3.0", []string{"1.0", "2.0", "main"}, []string{"main", "2.0", "1.0"}},
+		{"main", []string{"0.9", "v1.0", "1.1"}, []string{"1.1", "v1.0", "0.9"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nearestVersions(tt.version, tt.versions))
+		})
+	}
+}
+
+func TestPageNotFoundError(t *testing.T) {
+	err := &PageNotFoundError{ProductKey: "product1", Version: "0.9", PagePath: "guides/deploy", Versions: []string{"1.0", "main"}}
+
+	assert.Equal(t, "Page `guides/deploy` does not exist in version `0.9` of product `product1`. It exists in: 1.0, main.", err.Error())
+	assert.Equal(t, "/docs/product1/1.0/guides/deploy", err.PageUrl("1.0"))
+
+	err.Versions = nil
+	assert.Equal(t, "Page `guides/deploy` does not exist in version `0.9` of product `product1`.", err.Error())
+}
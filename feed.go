@@ -0,0 +1,364 @@
+package docweaver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Feed lists documentation changes: published versions and pages added or changed in the latest version.
+type Feed struct {
+	ID          string
+	Title       string
+	Author      string // name of the feed's author, which its entries inherit
+	Description string
+	Url         string
+	Updated     time.Time
+	Entries     []FeedEntry
+}
+
+// FeedEntry is a single documentation change.
+type FeedEntry struct {
+	ID      string
+	Title   string
+	Url     string
+	Summary string
+	Updated time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated,omitempty"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        rssGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGuid struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+const (
+	atomFeedFileName = "feed.atom"
+	rssFeedFileName  = "feed.rss"
+	atomXmlns        = "http://www.w3.org/2005/Atom"
+	maxFeedEntries   = 50
+	// feedTagPrefix prefixes IDs of feeds and entries if no site URL is configured, making them tag URIs (RFC 4151).
+	feedTagPrefix = "tag:docweaver,2024:"
+)
+
+// GetFeed returns the feed of documentation changes of the product with the given key, or of all products if
+// [productKey] is empty. Entries are ordered newest first. URLs are prefixed with the configured site URL; without
+// one, IDs are tag URIs, since Atom requires them to be absolute.
+func GetFeed(repo ProductRepository, productKey string) (*Feed, error) {
+	siteUrl := strings.TrimRight(GetSiteUrl(), "/")
+	var products []Product
+	if productKey == "" {
+		all, err := repo.FindAllProducts()
+		if err != nil {
+			return nil, err
+		}
+		products = all
+	} else {
+		p, err := repo.FindProduct(productKey)
+		if err != nil {
+			return nil, err
+		}
+		products = []Product{*p}
+	}
+
+	feed := &Feed{
+		Url:         siteUrl + routeRoot(),
+		Title:       "Documentation",
+		Author:      "Documentation",
+		Description: "Documentation changes.",
+	}
+	if productKey != "" {
+		feed.Url = fmt.Sprintf("%s%s", siteUrl, products[0].BaseUrl)
+		feed.Title = fmt.Sprintf("%s Documentation", products[0].Name)
+		feed.Author = products[0].Name
+		feed.Description = fmt.Sprintf("Documentation changes of %s.", products[0].Name)
+	}
+	feed.ID = feedId(feed.Url)
+
+	for i := range products {
+		entries, err := productFeedEntries(repo, &products[i], siteUrl)
+		if err != nil {
+			return nil, err
+		}
+		feed.Entries = append(feed.Entries, entries...)
+	}
+
+	sort.SliceStable(feed.Entries, func(i, j int) bool {
+		return feed.Entries[i].Updated.After(feed.Entries[j].Updated)
+	})
+	if len(feed.Entries) > maxFeedEntries {
+		feed.Entries = feed.Entries[:maxFeedEntries]
+	}
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	} else {
+		for i := range products {
+			if published, err := versionTime(&products[i], products[i].currentVersion()); err == nil && published.After(feed.Updated) {
+				feed.Updated = published
+			}
+		}
+	}
+
+	return feed, nil
+}
+
+// productFeedEntries lists the published versions of a product and the pages added or changed in its current version
+// compared to the version preceding it.
+func productFeedEntries(repo ProductRepository, product *Product, siteUrl string) ([]FeedEntry, error) {
+	var entries []FeedEntry
+	r := product.root
+
+	for _, version := range product.Versions {
+		if isMainVersion(version) {
+			continue
+		}
+
+		published, err := versionTime(product, version)
+		if err != nil {
+			return nil, err
+		}
+
+		url := fmt.Sprintf("%s%s/%s", siteUrl, product.BaseUrl, version)
+		entries = append(entries, FeedEntry{
+			ID:      feedId(url),
+			Title:   fmt.Sprintf("%s %s published", product.Name, version),
+			Url:     url,
+			Summary: fmt.Sprintf("Version %s of the %s documentation was published.", version, product.Name),
+			Updated: published,
+		})
+	}
+
+	version := product.currentVersion()
+	previous := previousVersion(product.Versions, version)
+	pagePaths, err := listPages(repo, product.Key(), version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pagePath := range pagePaths {
+		action, err := pageChange(product, previous, version, pagePath)
+		if err != nil {
+			return nil, err
+		}
+		if action == "" {
+			continue
+		}
+
+		page, err := repo.GetPage(product.Key(), version, pagePath)
+		if err != nil {
+			log(lWarn, "Failed to read page `%s` of product `%s`, version `%s` for feed. %s\n", pagePath, product.Key(), version, err)
+			continue
+		}
+		if page.Draft {
+			continue
+		}
+
+		_, modified := r.fileHistory(version, r.pageFilePath(version, pagePath))
+		summary := page.Description
+		if summary == "" {
+			summary = fmt.Sprintf("Page %s was %s in version %s of the %s documentation.", page.Title, action, version, product.Name)
+		}
+
+		url := siteUrl + page.Url()
+		entries = append(entries, FeedEntry{
+			ID:      feedId(fmt.Sprintf("%s#%d", url, modified.Unix())),
+			Title:   fmt.Sprintf("%s: %s %s", product.Name, page.Title, action),
+			Url:     url,
+			Summary: summary,
+			Updated: modified,
+		})
+	}
+
+	return entries, nil
+}
+
+// versionTime returns the time a version was published: the time of its commit, or the modification time of its
+// directory if it has no git history.
+func versionTime(product *Product, version string) (time.Time, error) {
+	if published, err := product.root.commitTime(version); err == nil {
+		return published, nil
+	}
+	fi, err := os.Stat(product.root.versionFilePath(version))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime().UTC(), nil
+}
+
+// previousVersion returns the published version preceding the given one, or an empty string if there is none. Main
+// versions follow all published versions.
+func previousVersion(versions []string, version string) string {
+	var published []string
+	for _, v := range versions {
+		if !isMainVersion(v) {
+			published = append(published, v)
+		}
+	}
+	sortVersions(published)
+
+	previous := ""
+	for _, v := range published {
+		if v == version {
+			break
+		}
+		previous = v
+	}
+	return previous
+}
+
+// pageChange returns how a page of the given version changed compared to the previous version: "added", "changed",
+// or an empty string if it is unchanged.
+func pageChange(product *Product, previous, version, pagePath string) (string, error) {
+	if previous == "" {
+		return "added", nil
+	}
+	md, err := os.ReadFile(product.root.pageFilePath(version, pagePath))
+	if err != nil {
+		return "", err
+	}
+	previousMd, err := os.ReadFile(product.root.pageFilePath(previous, pagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "added", nil
+		}
+		return "", err
+	}
+	if bytes.Equal(md, previousMd) {
+		return "", nil
+	}
+	return "changed", nil
+}
+
+// feedId returns the ID of a feed or entry with the given URL: the URL itself if it is absolute, a tag URI otherwise.
+func feedId(url string) string {
+	if strings.HasPrefix(url, "/") {
+		return feedTagPrefix + url
+	}
+	return url
+}
+
+// feedConfig returns the configuration feeds depend on, for use as part of cache keys.
+func feedConfig() string {
+	return fmt.Sprintf("%s|%s", GetSiteUrl(), GetRoutePrefix())
+}
+
+// Atom renders the feed as an Atom feed.
+func (f *Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		Xmlns:  atomXmlns,
+		ID:     f.ID,
+		Title:  f.Title,
+		Author: atomPerson{Name: f.Author},
+		Links: []atomLink{
+			{Href: f.Url},
+			{Href: fmt.Sprintf("%s/%s", f.Url, atomFeedFileName), Rel: "self"},
+		},
+	}
+	if !f.Updated.IsZero() {
+		feed.Updated = f.Updated.Format(time.RFC3339)
+	}
+	for _, e := range f.Entries {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: e.Updated.Format(time.RFC3339),
+			Link:    atomLink{Href: e.Url},
+			Summary: e.Summary,
+		})
+	}
+
+	return marshalXml(feed)
+}
+
+// Rss renders the feed as an RSS 2.0 feed.
+func (f *Feed) Rss() ([]byte, error) {
+	channel := rssChannel{Title: f.Title, Link: f.Url, Description: f.Description}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Url,
+			Guid:        rssGuid{Value: e.ID},
+			PubDate:     e.Updated.Format(time.RFC1123Z),
+			Description: e.Summary,
+		})
+	}
+
+	return marshalXml(rssFeed{Version: "2.0", Channel: channel})
+}
+
+// render renders the feed in the format matching the given feed file name.
+func (f *Feed) render(fileName string) ([]byte, error) {
+	if fileName == rssFeedFileName {
+		return f.Rss()
+	}
+	return f.Atom()
+}
+
+// isFeedFileName reports whether name is the file name of a feed.
+func isFeedFileName(name string) bool {
+	return name == atomFeedFileName || name == rssFeedFileName
+}
+
+// feedContentType returns the content type of the feed with the given file name.
+func feedContentType(fileName string) string {
+	if fileName == rssFeedFileName {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
@@ -0,0 +1,130 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetFeed(t *testing.T) {
+	t.Setenv(docweaver.EnvKeySiteUrl, "https://example.com")
+	feed, err := docweaver.GetFeed(repo, testProductKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	for i, e := range feed.Entries {
+		titles = append(titles, e.Title)
+		if i > 0 {
+			assert.False(t, e.Updated.After(feed.Entries[i-1].Updated), "entries must be ordered newest first")
+		}
+	}
+	assert.Equal(t, "Product One Documentation", feed.Title)
+	assert.Equal(t, fmt.Sprintf("https://example.com/docs/%s", testProductKey), feed.Url)
+	assert.Contains(t, titles, "Product One 1.0 published")
+	assert.Contains(t, titles, "Product One 0.9 published")
+	assert.Contains(t, titles, "Product One: Support added")
+	assert.NotContains(t, titles, "Product One: Deploy added", "only pages of the latest version are expected")
+
+	atom, err := feed.Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(atom), "<feed xmlns=\"http://www.w3.org/2005/Atom\">")
+	assert.Contains(t, string(atom), "<author>\n    <name>Product One</name>\n  </author>")
+	assert.Contains(t, string(atom), fmt.Sprintf("<id>https://example.com/docs/%s</id>", testProductKey))
+	assert.Contains(t, string(atom), fmt.Sprintf("<link href=\"https://example.com/docs/%s/1.0/support\"></link>", testProductKey))
+
+	rss, err := feed.Rss()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(rss), "<rss version=\"2.0\">")
+	assert.Contains(t, string(rss), "<title>Product One 1.0 published</title>")
+}
+
+func TestGetFeed_WithoutSiteUrl(t *testing.T) {
+	t.Setenv(docweaver.EnvKeySiteUrl, "")
+	feed, err := docweaver.GetFeed(repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "tag:docweaver,2024:/docs", feed.ID)
+	assert.Equal(t, "/docs", feed.Url)
+	assert.Equal(t, "Documentation", feed.Author)
+	for _, e := range feed.Entries {
+		assert.True(t, strings.HasPrefix(e.ID, "tag:docweaver,2024:/docs/"), e.ID)
+	}
+}
+
+func TestHttpHandler_ServeHTTP_Feeds(t *testing.T) {
+	testData := map[string]string{
+		fmt.Sprintf("%s/feed.atom", docweaver.GetRoutePrefix()):                   "application/atom+xml",
+		fmt.Sprintf("%s/%s/feed.rss", docweaver.GetRoutePrefix(), testProductKey): "application/rss+xml",
+	}
+
+	for path, contentType := range testData {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Get("Content-Type"), contentType)
+			assert.Contains(t, rec.Body.String(), "Product One 1.0 published")
+		})
+	}
+}
+
+func TestGetFeed_ChangedPagesOnly(t *testing.T) {
+	docs := t.TempDir()
+	files := map[string]string{
+		"changes/1.0/installation.md":  "# Installation",
+		"changes/1.0/configuration.md": "# Configuration",
+		"changes/2.0/installation.md":  "# Installation",
+		"changes/2.0/configuration.md": "# Configuration\n\nUpdated.",
+		"changes/2.0/support.md":       "# Support",
+		"empty/main/.docweaver.yml":    "name: Empty",
+	}
+	for name, content := range files {
+		path := filepath.Join(docs, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := docweaver.GetRepository(docs)
+
+	feed, err := docweaver.GetFeed(r, "changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, e := range feed.Entries {
+		titles = append(titles, e.Title)
+	}
+	assert.ElementsMatch(t, []string{"Changes 1.0 published", "Changes 2.0 published", "Changes: Configuration changed",
+		"Changes: Support added"}, titles)
+
+	feed, err = docweaver.GetFeed(r, "empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, feed.Entries)
+	assert.False(t, feed.Updated.IsZero(), "empty feeds are updated when their product was")
+
+	atom, err := (&docweaver.Feed{ID: "tag:example,2024:/docs", Title: "Nothing"}).Atom()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(atom), "<updated>")
+}
@@ -0,0 +1,93 @@
+package docweaver
+
+import (
+	"bytes"
+	"strings"
+
+	yml "gopkg.in/yaml.v3"
+)
+
+// frontMatter holds the known fields of a page's YAML front matter.
+type frontMatter struct {
+	Title       string
+	Description string
+	Keywords    stringList
+	Order       int
+	Draft       bool
+	Aliases     stringList
+	Layout      string
+}
+
+// stringList is a list of strings which may also be given as a single comma separated string in YAML.
+type stringList []string
+
+const frontMatterDelimiter = "---"
+
+func (l *stringList) UnmarshalYAML(value *yml.Node) error {
+	if value.Kind == yml.ScalarNode {
+		*l = nil
+		for _, s := range strings.Split(value.Value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				*l = append(*l, s)
+			}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// splitFrontMatter separates YAML front matter, delimited by "---" lines at the very top of a page, from the
+// markdown body. Raw front matter is nil if the page has none.
+func splitFrontMatter(md []byte) (raw []byte, body []byte) {
+	src := bytes.TrimPrefix(md, []byte("\xef\xbb\xbf"))
+	firstLine, rest, found := cutLine(src)
+	if !found || strings.TrimSpace(string(firstLine)) != frontMatterDelimiter {
+		return nil, md
+	}
+
+	for offset := 0; ; {
+		line, remaining, found := cutLine(rest[offset:])
+		if trimmed := strings.TrimSpace(string(line)); trimmed == frontMatterDelimiter || trimmed == "..." {
+			return rest[:offset], remaining
+		}
+		if !found {
+			return nil, md
+		}
+		offset = len(rest) - len(remaining)
+	}
+}
+
+// parseFrontMatter parses front matter from a page, returning the known fields, all fields as a map and the
+// markdown body with the front matter removed.
+func parseFrontMatter(md []byte) (fm frontMatter, meta map[string]interface{}, body []byte, err error) {
+	raw, body := splitFrontMatter(md)
+	if raw == nil {
+		return fm, nil, body, nil
+	}
+
+	if err = yml.Unmarshal(raw, &meta); err != nil {
+		return fm, nil, body, err
+	}
+	if err = yml.Unmarshal(raw, &fm); err != nil {
+		return fm, meta, body, err
+	}
+
+	return fm, meta, body, nil
+}
+
+// cutLine returns the first line of b (without line ending) and the remainder. found is false if b holds no line
+// ending.
+func cutLine(b []byte) (line, rest []byte, found bool) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return b, nil, false
+	}
+
+	return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:], true
+}
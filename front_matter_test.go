@@ -0,0 +1,48 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	md := []byte("---\ntitle: Foo\nkeywords: [a, b]\norder: 3\ndraft: true\naliases: old-foo\nteam: docs\n---\n# Heading\n")
+	fm, meta, body, err := parseFrontMatter(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Foo", fm.Title)
+	assert.Equal(t, stringList{"a", "b"}, fm.Keywords)
+	assert.Equal(t, 3, fm.Order)
+	assert.True(t, fm.Draft)
+	assert.Equal(t, stringList{"old-foo"}, fm.Aliases)
+	assert.Equal(t, "docs", meta["team"])
+	assert.Equal(t, "# Heading\n", string(body))
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	testData := []struct {
+		name         string
+		input        string
+		expectedRaw  string
+		expectedBody string
+	}{
+		{"none", "# Heading\n", "", "# Heading\n"},
+		{"crlf", "---\r\ntitle: Foo\r\n---\r\nBody", "title: Foo\r\n", "Body"},
+		{"empty", "---\n---\nBody", "", "Body"},
+		{"unterminated", "---\ntitle: Foo\nBody", "", "---\ntitle: Foo\nBody"},
+		{"thematic break later", "Intro\n---\nBody", "", "Intro\n---\nBody"},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			raw, body := splitFrontMatter([]byte(td.input))
+
+			assert.Equal(t, td.expectedRaw, string(raw))
+			assert.Equal(t, td.expectedBody, string(body))
+		})
+	}
+}
@@ -3,6 +3,7 @@ module github.com/reliqarts/go-docweaver
 go 1.17
 
 require (
+	github.com/alecthomas/chroma/v2 v2.2.0
 	github.com/otiai10/copy v1.14.0
 	github.com/reliqarts/go-common v0.0.11
 	github.com/stretchr/testify v1.7.0
@@ -14,7 +15,8 @@ require (
 )
 
 require (
-	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
@@ -0,0 +1,368 @@
+package docweaver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HttpHandler serves documentation pages and published assets over HTTP.
+type HttpHandler struct {
+	repo      ProductRepository
+	templates *template.Template
+	cache     contentCache // sitemaps and feeds
+}
+
+const (
+	pageTemplateName     = "page"
+	productsTemplateName = "products"
+	notFoundTemplateName = "not-found"
+)
+
+// TemplateFuncs are functions made available to the built-in templates. They may be added to custom templates
+// via template.Funcs.
+var TemplateFuncs = template.FuncMap{
+	"rawHtml": func(content string) template.HTML {
+		return template.HTML(content)
+	},
+	"tabsScript":     tabsScript,
+	"mathScript":     mathScript,
+	"diagramsScript": diagramsScript,
+}
+
+var defaultTemplates = template.Must(template.New("docweaver").Funcs(TemplateFuncs).Parse(`
+{{- define "page" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title>{{with .Product}}{{with .HighlightCssUrl}}<link rel="stylesheet" href="{{.}}">{{end}}{{end}}</head>
+<body>
+{{with .Index}}<nav>{{rawHtml .Content}}</nav>{{end}}
+<main>{{rawHtml .Content}}</main>
+{{tabsScript}}
+{{diagramsScript}}
+{{with .Product}}{{if .MathEnabled}}{{mathScript}}{{end}}{{end}}
+</body>
+</html>
+{{- end -}}
+{{- define "not-found" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Page not found</title></head>
+<body>
+<main><p>This page doesn't exist in {{.Version}}, see <a href="{{.PageUrl (index .Versions 0)}}">{{index .Versions 0}}</a>.</p></main>
+</body>
+</html>
+{{- end -}}
+{{- define "products" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Documentation</title></head>
+<body>
+<ul>{{range .}}<li><a href="{{.Url}}">{{.Name}}</a>{{with .Description}} - {{.}}{{end}}</li>{{end}}</ul>
+</body>
+</html>
+{{- end -}}
+`))
+
+// GetHttpHandler returns an HttpHandler serving pages from [repo]. Pages are rendered with the "page" template, the
+// product listing with the "products" template and pages missing in a version with the "not-found" template of
+// [templates]. Built-in templates are used for those not provided.
+func GetHttpHandler(repo ProductRepository, templates *template.Template) *HttpHandler {
+	if repo == nil {
+		repo = GetRepository("")
+	}
+	return &HttpHandler{repo: repo, templates: templates}
+}
+
+func (h *HttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if name := strings.TrimPrefix(r.URL.Path, "/"); isSitemapFileName(name) {
+		h.serveSitemap(w, r, name)
+		return
+	}
+
+	assetsPrefix := normalizeRoutePrefix(GetAssetsRoutePrefix())
+	if assetPath, ok := trimRoutePrefix(r.URL.Path, assetsPrefix); ok {
+		if strings.HasPrefix(assetPath, highlightCssRoute+"/") {
+			h.serveHighlightCss(w, r, strings.TrimPrefix(assetPath, highlightCssRoute+"/"))
+			return
+		}
+
+		w.Header().Set("Cache-Control", GetAssetCacheControl())
+		http.StripPrefix(assetsPrefix, http.FileServer(http.Dir(GetAssetsDir()))).ServeHTTP(w, r)
+		return
+	}
+
+	path, ok := trimRoutePrefix(r.URL.Path, normalizeRoutePrefix(GetRoutePrefix()))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	parts := strings.SplitN(path, "/", 3)
+	switch {
+	case path == "":
+		h.serveProducts(w, r)
+	case isFeedFileName(path):
+		h.serveFeed(w, r, "", path)
+	case len(parts) == 2 && isFeedFileName(parts[1]):
+		h.serveFeed(w, r, parts[0], parts[1])
+	case len(parts) == 1:
+		h.serveProduct(w, r, parts[0])
+	case len(parts) == 2:
+		h.servePage(w, r, parts[0], parts[1], "")
+	default:
+		h.servePage(w, r, parts[0], parts[1], parts[2])
+	}
+}
+
+func (h *HttpHandler) serveProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.repo.FindAllProducts()
+	if err != nil {
+		log(lError, "Failed to find products. %s\n", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", GetPageCacheControl())
+	h.render(w, productsTemplateName, products)
+}
+
+// serveSitemap serves one of the files generated by GenerateSitemap. Sitemaps are generated once and regenerated when
+// any product version changes; robots.txt is served without generating them.
+func (h *HttpHandler) serveSitemap(w http.ResponseWriter, r *http.Request, name string) {
+	if name == robotsFileName {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", GetPageCacheControl())
+		_, _ = w.Write(robotsTxt())
+		return
+	}
+
+	files, err := h.cache.get(h.repo, sitemapFileName, sitemapConfig(), func() (interface{}, error) {
+		return GenerateSitemap(h.repo)
+	})
+	if err != nil {
+		log(lError, "Failed to generate sitemap. %s\n", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	content, ok := files.(map[string][]byte)[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", GetPageCacheControl())
+	_, _ = w.Write(content)
+}
+
+// serveFeed serves the feed of the product with the given key, or of all products if the key is empty. Feeds are
+// generated once and regenerated when any product version changes.
+func (h *HttpHandler) serveFeed(w http.ResponseWriter, r *http.Request, productKey, fileName string) {
+	feed, err := h.cache.get(h.repo, "feed:"+productKey, feedConfig(), func() (interface{}, error) {
+		return GetFeed(h.repo, productKey)
+	})
+	if err != nil {
+		h.serveError(w, r, err)
+		return
+	}
+
+	content, err := feed.(*Feed).render(fileName)
+	if err != nil {
+		log(lError, "Failed to render feed `%s`. %s\n", fileName, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", feedContentType(fileName))
+	w.Header().Set("Cache-Control", GetPageCacheControl())
+	_, _ = w.Write(content)
+}
+
+// serveHighlightCss serves the syntax highlighting stylesheet with the given file name (<theme>.css).
+func (h *HttpHandler) serveHighlightCss(w http.ResponseWriter, r *http.Request, fileName string) {
+	if !strings.HasSuffix(fileName, ".css") {
+		http.NotFound(w, r)
+		return
+	}
+
+	css, err := HighlightCss(strings.TrimSuffix(fileName, ".css"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", GetAssetCacheControl())
+	_, _ = w.Write([]byte(css))
+}
+
+// serveProduct redirects to the latest version of the product with the given key.
+func (h *HttpHandler) serveProduct(w http.ResponseWriter, r *http.Request, productKey string) {
+	product, err := h.repo.FindProduct(productKey)
+	if err != nil {
+		h.serveError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("%s/%s", product.BaseUrl, product.currentVersion()), http.StatusFound)
+}
+
+func (h *HttpHandler) servePage(w http.ResponseWriter, r *http.Request, productKey, version, pagePath string) {
+	page, err := h.repo.GetPage(productKey, version, pagePath)
+	if err != nil {
+		h.serveError(w, r, err)
+		return
+	}
+
+	etag, lastModified := pageValidators(page)
+	w.Header().Set("Cache-Control", GetPageCacheControl())
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.render(w, pageLayout(h.templates, page), page)
+}
+
+// serveError responds to a failed lookup. Rejected input results in 400 Bad Request, anything else in 404 Not Found.
+// Pages existing in other versions are redirected to their fallback version, or rendered with the "not-found"
+// template pointing to the nearest version.
+func (h *HttpHandler) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	var ve ValidationError
+	if errors.As(err, &ve) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var nfe *PageNotFoundError
+	if errors.As(err, &nfe) && nfe.Fallback != "" {
+		http.Redirect(w, r, nfe.PageUrl(nfe.Fallback), http.StatusFound)
+		return
+	}
+	if errors.As(err, &nfe) && len(nfe.Versions) > 0 {
+		h.renderStatus(w, http.StatusNotFound, notFoundTemplateName, nfe)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// render executes the named template with the given data. The output is buffered so that template errors may still
+// be reported with an appropriate status code.
+func (h *HttpHandler) render(w http.ResponseWriter, name string, data interface{}) {
+	h.renderStatus(w, http.StatusOK, name, data)
+}
+
+// renderStatus renders the named template like render, responding with the given status code.
+func (h *HttpHandler) renderStatus(w http.ResponseWriter, status int, name string, data interface{}) {
+	var out bytes.Buffer
+	if err := lookupTemplate(h.templates, name).Execute(&out, data); err != nil {
+		log(lError, "Failed to render template `%s`. %s\n", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = out.WriteTo(w)
+}
+
+// lookupTemplate returns the named template from templates, falling back to the built-in templates.
+func lookupTemplate(templates *template.Template, name string) *template.Template {
+	if templates != nil && templates.Lookup(name) != nil {
+		return templates.Lookup(name)
+	}
+
+	return defaultTemplates.Lookup(name)
+}
+
+// pageLayout returns the name of the template a page is rendered with: the layout set in its front matter if
+// templates provides it, the page template otherwise.
+func pageLayout(templates *template.Template, page *Page) string {
+	if page.Layout != "" && templates != nil && templates.Lookup(page.Layout) != nil {
+		return page.Layout
+	}
+
+	return pageTemplateName
+}
+
+// pageValidators returns the ETag and last modification time for a page. The page hash covers its index already.
+func pageValidators(page *Page) (etag string, lastModified time.Time) {
+	lastModified = page.LastModified
+	if page.Index != nil && page.Index.LastModified.After(lastModified) {
+		lastModified = page.Index.LastModified
+	}
+
+	return fmt.Sprintf("\"%s\"", page.Hash), lastModified
+}
+
+// notModified reports whether the conditional headers of a request match the given validators.
+// If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// normalizeRoutePrefix ensures a route prefix starts with a slash and has no trailing slash. The root prefix (`/`) is
+// normalized to an empty string, so that URLs joined to it don't start with `//`.
+func normalizeRoutePrefix(prefix string) string {
+	if prefix = strings.Trim(prefix, "/"); prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// routeRoot returns the URL path of the product listing.
+func routeRoot() string {
+	if prefix := normalizeRoutePrefix(GetRoutePrefix()); prefix != "" {
+		return prefix
+	}
+	return "/"
+}
+
+// trimRoutePrefix removes the route prefix from a URL path. The second return value reports whether the path is
+// within the route prefix.
+func trimRoutePrefix(path, prefix string) (string, bool) {
+	if path == prefix {
+		return "", true
+	}
+	if !strings.HasPrefix(path, prefix+"/") {
+		return "", false
+	}
+
+	return strings.Trim(strings.TrimPrefix(path, prefix), "/"), true
+}
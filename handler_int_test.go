@@ -0,0 +1,145 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var handler = docweaver.GetHttpHandler(repo, nil)
+
+func TestHttpHandler_ServeHTTP(t *testing.T) {
+	pageUrl := fmt.Sprintf("%s/%s/1.0/installation", docweaver.GetRoutePrefix(), testProductKey)
+
+	t.Run("page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pageUrl, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Product 1")
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+		assert.NotEmpty(t, rec.Header().Get("Last-Modified"))
+		assert.Equal(t, docweaver.GetPageCacheControl(), rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("nested page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s/main/guides/advanced/caching", docweaver.GetRoutePrefix(), testProductKey), nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Advanced caching")
+		assert.Contains(t, rec.Body.String(), "_highlight/monokai.css")
+		assert.Contains(t, rec.Body.String(), "katex.min.js")
+	})
+
+	t.Run("products", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, docweaver.GetRoutePrefix(), nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Product One")
+	})
+
+	t.Run("product redirect", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s", docweaver.GetRoutePrefix(), testProductKey), nil))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, fmt.Sprintf("%s/%s/1.0", docweaver.GetRoutePrefix(), testProductKey), rec.Header().Get("Location"))
+	})
+
+	t.Run("highlight css", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/_highlight/monokai.css", docweaver.GetAssetsRoutePrefix()), nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/css")
+		assert.Contains(t, rec.Body.String(), ".chroma")
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/_highlight/no-such-theme.css", docweaver.GetAssetsRoutePrefix()), nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("page traversal", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pageUrl+"/../../../../readme", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("missing page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pageUrl+"-missing", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("root route prefix", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeyRoutePrefix, "/")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+testProductKey, nil))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, fmt.Sprintf("/%s/1.0", testProductKey), rec.Header().Get("Location"))
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s/1.0/installation", testProductKey), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("page missing in version", func(t *testing.T) {
+		missingUrl := fmt.Sprintf("%s/%s/0.9/support", docweaver.GetRoutePrefix(), testProductKey)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, missingUrl, nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), fmt.Sprintf(
+			"This page doesn't exist in 0.9, see <a href=\"%s/%s/1.0/support\">1.0</a>.", docweaver.GetRoutePrefix(), testProductKey,
+		))
+
+		t.Setenv(docweaver.EnvKeyVersionFallback, docweaver.VersionFallbackRedirect)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, missingUrl, nil))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, fmt.Sprintf("%s/%s/1.0/support", docweaver.GetRoutePrefix(), testProductKey), rec.Header().Get("Location"))
+	})
+}
+
+func TestHttpHandler_ServeHTTP_ConditionalRequests(t *testing.T) {
+	pageUrl := fmt.Sprintf("%s/%s/main/installation", docweaver.GetRoutePrefix(), testProductKey)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pageUrl, nil))
+	etag, lastModified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+
+	testData := []struct {
+		name     string
+		header   string
+		value    string
+		expected int
+	}{
+		{"matching etag", "If-None-Match", etag, http.StatusNotModified},
+		{"matching etag in list", "If-None-Match", fmt.Sprintf("\"foo\", W/%s", etag), http.StatusNotModified},
+		{"stale etag", "If-None-Match", "\"foo\"", http.StatusOK},
+		{"not modified since", "If-Modified-Since", lastModified, http.StatusNotModified},
+		{"modified since", "If-Modified-Since", "Mon, 02 Jan 2006 15:04:05 GMT", http.StatusOK},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, pageUrl, nil)
+			req.Header.Set(td.header, td.value)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, td.expected, rec.Code)
+			assert.Equal(t, etag, rec.Header().Get("ETag"))
+		})
+	}
+}
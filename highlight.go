@@ -0,0 +1,236 @@
+package docweaver
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// highlightConfig configures syntax highlighting of fenced code blocks. It is read from the `highlight` key of a
+// product's meta file.
+type highlightConfig struct {
+	Enabled     *bool  `yaml:"enabled"`      // defaults to false, leaving code blocks as plain <pre><code> markup
+	Theme       string `yaml:"theme"`        // chroma style name, defaults to "github"
+	LineNumbers bool   `yaml:"line_numbers"` // whether line numbers are shown
+}
+
+// fenceInfo is the parsed info string of a fenced code block, e.g. "go {3,5-7} title=main.go".
+type fenceInfo struct {
+	Lang  string
+	Lines [][2]int          // highlighted line ranges (inclusive)
+	Attrs map[string]string // key=value attributes
+}
+
+// highlighter renders fenced code blocks with chroma, using CSS classes for styling. The configuration of the page's
+// product is attached to the document when parsing; config applies to documents parsed without a page context.
+type highlighter struct {
+	config highlightConfig
+}
+
+// nodeRendererFuncs collects the render functions registered by a renderer.NodeRenderer.
+type nodeRendererFuncs map[ast.NodeKind]renderer.NodeRendererFunc
+
+type codePreWrapper struct {
+	lang string
+}
+
+const (
+	defaultHighlightTheme = "github"
+	// highlightCssRoute is the route, within the assets route prefix, highlighting stylesheets are served from.
+	highlightCssRoute = "_highlight"
+	// highlightConfigMetaKey is the document meta key of the highlight configuration of the page's product.
+	highlightConfigMetaKey = "docweaver.highlight"
+)
+
+var (
+	fenceLineRangesPattern = regexp.MustCompile(`\{([^{}=]*)\}`)
+	fenceAttrPattern       = regexp.MustCompile(`([\w-]+)=("[^"]*"|\S+)`)
+	// plainFencedCodeBlock renders fenced code blocks as goldmark does, for products with highlighting disabled.
+	plainFencedCodeBlock = func() renderer.NodeRendererFunc {
+		funcs := nodeRendererFuncs{}
+		goldmarkhtml.NewRenderer().RegisterFuncs(funcs)
+		return funcs[ast.KindFencedCodeBlock]
+	}()
+)
+
+func (c highlightConfig) enabled() bool {
+	return c.Enabled != nil && *c.Enabled
+}
+
+func (c highlightConfig) theme() string {
+	if c.Theme == "" {
+		return defaultHighlightTheme
+	}
+	return c.Theme
+}
+
+// HighlightCss returns the stylesheet for syntax highlighted code blocks using the given theme (chroma style).
+func HighlightCss(theme string) (string, error) {
+	style, ok := styles.Registry[theme]
+	if !ok {
+		return "", simpleError{fmt.Sprintf("Unknown highlight theme `%s`.", theme)}
+	}
+
+	var css bytes.Buffer
+	if err := chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&css, style); err != nil {
+		return "", err
+	}
+
+	return css.String(), nil
+}
+
+// highlightCssUrl returns the URL of the stylesheet for the given theme.
+func highlightCssUrl(theme string) string {
+	return fmt.Sprintf("%s/%s/%s.css", normalizeRoutePrefix(GetAssetsRoutePrefix()), highlightCssRoute, theme)
+}
+
+// parseFenceInfo parses the info string of a fenced code block.
+func parseFenceInfo(info string) fenceInfo {
+	fi := fenceInfo{Attrs: map[string]string{}}
+	info = strings.TrimSpace(info)
+
+	if m := fenceLineRangesPattern.FindStringSubmatchIndex(info); m != nil {
+		fi.Lines = parseLineRanges(info[m[2]:m[3]])
+		info = info[:m[0]] + " " + info[m[1]:]
+	}
+	for _, m := range fenceAttrPattern.FindAllStringSubmatch(info, -1) {
+		fi.Attrs[m[1]] = strings.Trim(m[2], "\"")
+	}
+	info = fenceAttrPattern.ReplaceAllString(info, "")
+
+	if fields := strings.Fields(info); len(fields) > 0 {
+		fi.Lang = strings.ToLower(fields[0])
+	}
+
+	return fi
+}
+
+// parseLineRanges parses line ranges such as "3,5-7" into inclusive ranges. Invalid ranges are ignored.
+func parseLineRanges(spec string) (ranges [][2]int) {
+	for _, part := range strings.Split(spec, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		from, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			continue
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil || to < from {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+
+	return
+}
+
+// fencedCodeText returns the content of a fenced code block.
+func fencedCodeText(n *ast.FencedCodeBlock, source []byte) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(source))
+	}
+
+	return b.String()
+}
+
+func (f nodeRendererFuncs) Register(kind ast.NodeKind, fn renderer.NodeRendererFunc) {
+	f[kind] = fn
+}
+
+func (h *highlighter) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(h, 300)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(h, 200)))
+}
+
+// Transform attaches the highlight configuration of the page's product to the document.
+func (h *highlighter) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if ctx, ok := pc.Get(pageContextKey).(*pageContext); ok {
+		doc.AddMeta(highlightConfigMetaKey, ctx.highlight)
+	}
+}
+
+func (h *highlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, h.renderFencedCodeBlock)
+}
+
+func (h *highlighter) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	config := h.config
+	if doc := node.OwnerDocument(); doc != nil {
+		if c, ok := doc.Meta()[highlightConfigMetaKey].(highlightConfig); ok {
+			config = c
+		}
+	}
+	if !config.enabled() {
+		return plainFencedCodeBlock(w, source, node, entering)
+	}
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.FencedCodeBlock)
+	var info fenceInfo
+	if n.Info != nil {
+		info = parseFenceInfo(string(n.Info.Segment.Value(source)))
+	}
+	code := fencedCodeText(n, source)
+
+	lexer := lexers.Get(info.Lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(config.LineNumbers),
+		chromahtml.HighlightLines(info.Lines),
+		chromahtml.WithPreWrapper(codePreWrapper{lang: info.Lang}),
+	)
+	if err := formatter.Format(w, styles.Get(config.theme()), iterator); err != nil {
+		return ast.WalkStop, err
+	}
+	_, _ = w.WriteString("\n")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (p codePreWrapper) Start(code bool, styleAttr string) string {
+	if !code {
+		return fmt.Sprintf("<pre%s>", styleAttr)
+	}
+	if p.lang == "" {
+		return fmt.Sprintf("<pre%s><code>", styleAttr)
+	}
+
+	lang := html.EscapeString(p.lang)
+	return fmt.Sprintf("<pre%s><code class=\"language-%s\" data-lang=\"%s\">", styleAttr, lang, lang)
+}
+
+func (p codePreWrapper) End(code bool) string {
+	if code {
+		return "</code></pre>"
+	}
+	return "</pre>"
+}
@@ -0,0 +1,90 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"testing"
+)
+
+func TestParseFenceInfo(t *testing.T) {
+	tests := []struct {
+		info     string
+		expected fenceInfo
+	}{
+		{"", fenceInfo{Attrs: map[string]string{}}},
+		{"Go", fenceInfo{Lang: "go", Attrs: map[string]string{}}},
+		{"go {3,5-7}", fenceInfo{Lang: "go", Lines: [][2]int{{3, 3}, {5, 7}}, Attrs: map[string]string{}}},
+		{"go{1}", fenceInfo{Lang: "go", Lines: [][2]int{{1, 1}}, Attrs: map[string]string{}}},
+		{"js title=\"app.js\" tab=node", fenceInfo{Lang: "js", Attrs: map[string]string{"title": "app.js", "tab": "node"}}},
+		{"sh {2-1,x,4} tab=bash", fenceInfo{Lang: "sh", Lines: [][2]int{{4, 4}}, Attrs: map[string]string{"tab": "bash"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseFenceInfo(tt.info))
+		})
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	enabled := true
+	render := func(config highlightConfig, source string) string {
+		config.Enabled = &enabled
+		var out bytes.Buffer
+		gm := goldmark.New(goldmark.WithExtensions(&highlighter{config: config}))
+		if err := gm.Convert([]byte(source), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	t.Run("language", func(t *testing.T) {
+		out := render(highlightConfig{}, "```go {2}\npackage main\nfunc main() {}\n```\n")
+
+		assert.Contains(t, out, "<pre class=\"chroma\"><code class=\"language-go\" data-lang=\"go\">")
+		assert.Contains(t, out, "<span class=\"kn\">package</span>")
+		assert.Contains(t, out, "<span class=\"line hl\">")
+	})
+
+	t.Run("unknown language", func(t *testing.T) {
+		out := render(highlightConfig{}, "```nosuchlang\n<b>x</b>\n```\n")
+
+		assert.Contains(t, out, "<code class=\"language-nosuchlang\"")
+		assert.Contains(t, out, "&lt;b&gt;x&lt;/b&gt;")
+	})
+
+	t.Run("no language", func(t *testing.T) {
+		out := render(highlightConfig{}, "```\nplain\n```\n")
+
+		assert.Contains(t, out, "<pre class=\"chroma\"><code>")
+	})
+
+	t.Run("line numbers", func(t *testing.T) {
+		out := render(highlightConfig{LineNumbers: true}, "```go\na\nb\n```\n")
+
+		assert.Contains(t, out, "class=\"ln\"")
+	})
+}
+
+func TestHighlightCss(t *testing.T) {
+	css, err := HighlightCss("monokai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, css, ".chroma")
+
+	_, err = HighlightCss("no-such-theme")
+	assert.Error(t, err)
+}
+
+func TestHighlightConfig(t *testing.T) {
+	enabled, disabled := true, false
+	assert.False(t, highlightConfig{}.enabled())
+	assert.False(t, highlightConfig{Enabled: &disabled}.enabled())
+	assert.True(t, highlightConfig{Enabled: &enabled}.enabled())
+	assert.Equal(t, defaultHighlightTheme, highlightConfig{}.theme())
+	assert.Equal(t, "dracula", highlightConfig{Theme: "dracula"}.theme())
+}
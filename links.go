@@ -0,0 +1,281 @@
+package docweaver
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/reliqarts/go-common"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// pageContext describes the page being rendered. It is passed to extensions via the parser context.
+type pageContext struct {
+	repo        ProductRepository
+	productKey  string
+	version     string
+	dir         string // directory of the page's file relative to the version root, slash separated
+	highlight   highlightConfig
+	math        bool
+	diagnostics []Diagnostic
+}
+
+// linkRewriter rewrites links to page and asset URLs:
+//   - relative links to markdown files (`./support.md#help`), so links working on GitHub work in docweaver too
+//   - relative image paths within the `images` directory, to the published assets of the version
+//   - docweaver links (`dw://product/version/page#anchor`), where version may be `latest`
+//   - wiki links (`[[product:page#anchor|label]]` or `[[page]]`)
+type linkRewriter struct{}
+
+type wikiLinkParser struct{}
+
+const (
+	dwLinkScheme       = "dw://"
+	latestVersionAlias = "latest"
+)
+
+var pageContextKey = parser.NewContextKey()
+
+// newPageContext returns the context of the page with the given slash separated file path, relative to the version
+// root.
+func newPageContext(repo ProductRepository, productKey, version, relFilePath string) *pageContext {
+	dir := path.Dir(relFilePath)
+	if dir == "." {
+		dir = ""
+	}
+
+	return &pageContext{repo: repo, productKey: productKey, version: version, dir: dir}
+}
+
+// warn records a warning diagnostic.
+func (c *pageContext) warn(format string, v ...interface{}) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Severity: SeverityWarning, Message: fmt.Sprintf(format, v...)})
+}
+
+// rewriteDwLink returns the URL of a docweaver link (`dw://product/version/page#anchor`), or the destination unchanged.
+// Links to unknown products, versions or pages are reported as warnings and left unchanged.
+func (c *pageContext) rewriteDwLink(destination string) string {
+	if !strings.HasPrefix(destination, dwLinkScheme) {
+		return destination
+	}
+
+	target, fragment := destination[len(dwLinkScheme):], ""
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target, fragment = target[:i], target[i:]
+	}
+	parts := strings.SplitN(strings.Trim(target, "/"), "/", 3)
+	productKey, version, pagePath := parts[0], latestVersionAlias, ""
+	if len(parts) > 1 {
+		version = parts[1]
+	}
+	if len(parts) > 2 {
+		pagePath = parts[2]
+	}
+
+	if c.repo == nil {
+		return destination
+	}
+	product, err := c.repo.FindProduct(productKey)
+	if err != nil || len(product.Versions) == 0 {
+		c.warn("Unknown product `%s` in link `%s`.", productKey, destination)
+		return destination
+	}
+	if version == latestVersionAlias {
+		version = product.currentVersion()
+	}
+	if len(common.Intersection(product.Versions, []string{version})) == 0 {
+		c.warn("Unknown version `%s` of product `%s` in link `%s`.", version, productKey, destination)
+		return destination
+	}
+
+	link := fmt.Sprintf("%s/%s", product.BaseUrl, version)
+	if pagePath != "" {
+		if err := validatePagePath(pagePath); err != nil {
+			c.warn("Invalid page path `%s` in link `%s`.", pagePath, destination)
+			return destination
+		}
+		if _, err := os.Stat(product.root.pageFilePath(version, pagePath)); err != nil {
+			c.warn("Unknown page `%s` of product `%s`, version `%s` in link `%s`.", pagePath, productKey, version, destination)
+			return destination
+		}
+		link += "/" + pagePath
+	}
+
+	return link + fragment
+}
+
+// wikiLinkDestination returns the docweaver link of a wiki link target (`product:page#anchor` or `page#anchor`).
+// Links to pages of the same product refer to the same version, others to the latest version.
+func (c *pageContext) wikiLinkDestination(target string) string {
+	productKey, pagePath := c.productKey, target
+	if i := strings.IndexByte(target, ':'); i >= 0 {
+		productKey, pagePath = target[:i], target[i+1:]
+	}
+	version := latestVersionAlias
+	if productKey == c.productKey {
+		version = c.version
+	}
+
+	return fmt.Sprintf("%s%s/%s/%s", dwLinkScheme, productKey, version, strings.TrimLeft(pagePath, "/"))
+}
+
+// versionUrl returns the URL of the page's version.
+func (c *pageContext) versionUrl() string {
+	return fmt.Sprintf("%s/%s/%s", normalizeRoutePrefix(GetRoutePrefix()), c.productKey, c.version)
+}
+
+// assetsUrl returns the URL of the published assets of the page's version.
+func (c *pageContext) assetsUrl() string {
+	return fmt.Sprintf("%s/%s/%s", normalizeRoutePrefix(GetAssetsRoutePrefix()), c.productKey, c.version)
+}
+
+// resolve resolves a relative path against the page's directory. False is returned for paths leaving the version root.
+func (c *pageContext) resolve(relPath string) (string, bool) {
+	resolved := path.Join(c.dir, relPath)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false
+	}
+	if resolved == "." {
+		resolved = ""
+	}
+
+	return resolved, true
+}
+
+// relativeUrl parses destination if it is a relative URL with a path, such as `guides/config.md#env`.
+func relativeUrl(destination string) (*url.URL, bool) {
+	if destination == "" || strings.HasPrefix(destination, "/") || strings.HasPrefix(destination, "#") ||
+		strings.Contains(destination, "{{") {
+		return nil, false
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// rewritePageLink returns the page URL of a relative link to a markdown file, or the destination unchanged.
+func (c *pageContext) rewritePageLink(destination string) string {
+	u, ok := relativeUrl(destination)
+	if !ok || path.Ext(u.Path) != "."+pageExt {
+		return destination
+	}
+
+	pagePath, ok := c.resolve(strings.TrimSuffix(u.Path, "."+pageExt))
+	if !ok {
+		return destination
+	}
+	pagePath = normalizeNavPagePath(pagePath)
+
+	link := c.versionUrl()
+	if pagePath != "" {
+		link += "/" + pagePath
+	}
+	if u.RawQuery != "" {
+		link += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		link += "#" + u.Fragment
+	}
+
+	return link
+}
+
+// rewriteImageLink returns the asset URL of a relative image path, or the destination unchanged. Only the `images`
+// directory of a version is published, so images elsewhere are reported as warnings and left unchanged.
+func (c *pageContext) rewriteImageLink(destination string) string {
+	u, ok := relativeUrl(destination)
+	if !ok {
+		return destination
+	}
+
+	assetPath, ok := c.resolve(u.Path)
+	if !ok || assetPath == "" {
+		return destination
+	}
+	if !strings.HasPrefix(assetPath, imgDirName+"/") {
+		c.warn("Image `%s` is not within the `%s` directory, which is the only one published.", destination, imgDirName)
+		return destination
+	}
+
+	return fmt.Sprintf("%s/%s", c.assetsUrl(), assetPath)
+}
+
+func (l *linkRewriter) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&wikiLinkParser{}, 199)),
+		parser.WithASTTransformers(util.Prioritized(l, 200)),
+	)
+}
+
+func (l *linkRewriter) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ctx, ok := pc.Get(pageContextKey).(*pageContext)
+	if !ok {
+		return
+	}
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Link:
+			destination := ctx.rewriteDwLink(string(node.Destination))
+			node.Destination = []byte(ctx.rewritePageLink(destination))
+		case *ast.Image:
+			node.Destination = []byte(ctx.rewriteImageLink(string(node.Destination)))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func (p *wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+// Parse parses wiki links such as `[[product:page#anchor|label]]` into links to docweaver link destinations. The
+// target is used as the label if none is given.
+func (p *wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	ctx, ok := pc.Get(pageContextKey).(*pageContext)
+	line, _ := block.PeekLine()
+	if !ok || !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line, []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	content := string(line[2:end])
+	// `[[1]](url)` is a link labelled `[1]`, not a wiki link
+	if strings.TrimSpace(content) == "" || strings.ContainsAny(content, "[]") || bytes.HasPrefix(line[end+2:], []byte("(")) {
+		return nil
+	}
+
+	target, label := content, content
+	if i := strings.IndexByte(content, '|'); i >= 0 {
+		target, label = content[:i], content[i+1:]
+	}
+	target, label = strings.TrimSpace(target), strings.TrimSpace(label)
+	if target == "" {
+		return nil
+	}
+	block.Advance(end + 2)
+
+	link := ast.NewLink()
+	link.Destination = []byte(ctx.wikiLinkDestination(target))
+	link.AppendChild(link, ast.NewString([]byte(label)))
+
+	return link
+}
@@ -0,0 +1,101 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"testing"
+)
+
+func TestPageContext_RewritePageLink(t *testing.T) {
+	ctx := newPageContext(nil, "foo", "1.0", "guides/deploy.md")
+	tests := []struct {
+		destination string
+		expected    string
+	}{
+		{"./support.md", "/docs/foo/1.0/guides/support"},
+		{"config.md#env", "/docs/foo/1.0/guides/config#env"},
+		{"advanced/caching.md?x=1#top", "/docs/foo/1.0/guides/advanced/caching?x=1#top"},
+		{"../installation.md", "/docs/foo/1.0/installation"},
+		{"index.md", "/docs/foo/1.0/guides"},
+		{"../index.md", "/docs/foo/1.0"},
+		{"../../outside.md", "../../outside.md"},
+		{"support", "support"},
+		{"#anchor", "#anchor"},
+		{"/docs/foo/1.0/support", "/docs/foo/1.0/support"},
+		{"https://example.com/readme.md", "https://example.com/readme.md"},
+		{"{{docs}}/support", "{{docs}}/support"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ctx.rewritePageLink(tt.destination))
+		})
+	}
+}
+
+func TestPageContext_RewriteImageLink(t *testing.T) {
+	ctx := newPageContext(nil, "foo", "1.0", "guides/deploy.md")
+	tests := []struct {
+		destination string
+		expected    string
+	}{
+		{"../images/a.png", "/doc-assets/foo/1.0/images/a.png"},
+		{"diagram.svg", "diagram.svg"},
+		{"../screens/a.png", "../screens/a.png"},
+		{"../../../etc/a.png", "../../../etc/a.png"},
+		{"/static/a.png", "/static/a.png"},
+		{"https://example.com/a.png", "https://example.com/a.png"},
+		{"{{docs}}/images/a.png", "{{docs}}/images/a.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ctx.rewriteImageLink(tt.destination))
+		})
+	}
+	if assert.Len(t, ctx.diagnostics, 2) {
+		assert.Equal(t, "Image `diagram.svg` is not within the `images` directory, which is the only one published.", ctx.diagnostics[0].Message)
+	}
+}
+
+func TestLinkRewriter(t *testing.T) {
+	source := []byte("[Support](support.md#help) ![Logo](images/logo.png)\n")
+	gm := goldmark.New(goldmark.WithExtensions(&linkRewriter{}))
+	pc := parser.NewContext()
+	pc.Set(pageContextKey, newPageContext(nil, "foo", "main", "installation.md"))
+
+	var out bytes.Buffer
+	if err := gm.Renderer().Render(&out, source, gm.Parser().Parse(text.NewReader(source), parser.WithContext(pc))); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "<p><a href=\"/docs/foo/main/support#help\">Support</a> <img src=\"/doc-assets/foo/main/images/logo.png\" alt=\"Logo\"></p>\n", out.String())
+}
+
+func TestPageContext_WikiLinkDestination(t *testing.T) {
+	ctx := newPageContext(nil, "foo", "1.0", "installation.md")
+
+	assert.Equal(t, "dw://foo/1.0/support", ctx.wikiLinkDestination("support"))
+	assert.Equal(t, "dw://foo/1.0/guides/deploy#top", ctx.wikiLinkDestination("foo:guides/deploy#top"))
+	assert.Equal(t, "dw://bar/latest/installation", ctx.wikiLinkDestination("bar:installation"))
+}
+
+func TestWikiLinkParser(t *testing.T) {
+	source := []byte("[[bar:setup#env|Set up]], [[support]], [[ ]], [[a [b]]], [[1]](y) and [link](x).\n")
+	gm := goldmark.New(goldmark.WithExtensions(&linkRewriter{}))
+	pc := parser.NewContext()
+	pc.Set(pageContextKey, newPageContext(nil, "foo", "main", "installation.md"))
+
+	var out bytes.Buffer
+	if err := gm.Renderer().Render(&out, source, gm.Parser().Parse(text.NewReader(source), parser.WithContext(pc))); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "<p><a href=\"dw://bar/latest/setup#env\">Set up</a>, <a href=\"dw://foo/main/support\">support</a>, "+
+		"[[ ]], [[a [b]]], <a href=\"y\">[1]</a> and <a href=\"x\">link</a>.</p>\n", out.String())
+}
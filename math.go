@@ -0,0 +1,256 @@
+package docweaver
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// InlineMath is TeX math within a paragraph, written as `$...$` (or `$$...$$` for display style).
+type InlineMath struct {
+	ast.BaseInline
+	Display bool
+	Value   []byte // TeX source, without delimiters
+}
+
+// DisplayMath is a block of TeX math, written between `$$` lines or as a `$$...$$` line.
+type DisplayMath struct {
+	ast.BaseBlock
+	closed bool // whether the closing `$$` was read
+}
+
+var (
+	// KindInlineMath is the ast.NodeKind of InlineMath nodes.
+	KindInlineMath = ast.NewNodeKind("InlineMath")
+	// KindDisplayMath is the ast.NodeKind of DisplayMath nodes.
+	KindDisplayMath = ast.NewNodeKind("DisplayMath")
+)
+
+// mathExtension renders math as KaTeX compatible markup: `<span class="math inline">\(...\)</span>` and
+// `<div class="math display">\[...\]</div>`. Math is only parsed for pages of products enabling it; parsers used
+// without a page context always parse it.
+type mathExtension struct{}
+
+type inlineMathParser struct{}
+
+type displayMathParser struct{}
+
+const mathDelimiter = '$'
+
+var displayMathFence = []byte("$$")
+
+const (
+	// katexVersion is the version of KaTeX loaded by MathScript; the integrity hashes are those of its files.
+	katexVersion      = "0.16.9"
+	katexCssIntegrity = "sha384-n8MVd4RsNIU0tAv4ct0nTaAbDJwPJzDEaqSD1odI+WdtXRGWt2kTvGFasHpSy3SV"
+	katexJsIntegrity  = "sha384-XjKyOOlGwcjNTAIQHIpgOno0Hl1YQqzUOEleOLALmuqehneUG+vnGctmUb0ZY0l8"
+)
+
+// MathScript renders math elements with KaTeX, loaded from the default CDN with subresource integrity. The
+// `mathScript` template function, which the built-in page template includes for products enabling math, loads KaTeX
+// from the base URL configured via DW_MATH_ASSETS_URL instead.
+const MathScript = `<link rel="stylesheet" href="` + defaultMathAssetsUrl + `/katex.min.css" integrity="` + katexCssIntegrity + `" crossorigin="anonymous">
+<script src="` + defaultMathAssetsUrl + `/katex.min.js" integrity="` + katexJsIntegrity + `" crossorigin="anonymous"></script>
+<script>
+document.querySelectorAll('.math.inline, .math.display').forEach(function (el) {
+  var tex = el.textContent.replace(/^\\[(\[]|\\[)\]]$/g, '');
+  katex.render(tex, el, {displayMode: el.classList.contains('display'), throwOnError: false});
+});
+</script>`
+
+func (n *InlineMath) Kind() ast.NodeKind {
+	return KindInlineMath
+}
+
+func (n *InlineMath) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Value": string(n.Value)}, nil)
+}
+
+func (n *DisplayMath) Kind() ast.NodeKind {
+	return KindDisplayMath
+}
+
+func (n *DisplayMath) IsRaw() bool {
+	return true
+}
+
+func (n *DisplayMath) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathScript returns MathScript, loading KaTeX from the configured assets URL, for use in templates.
+func mathScript() template.HTML {
+	return template.HTML(strings.ReplaceAll(MathScript, defaultMathAssetsUrl, html.EscapeString(GetMathAssetsUrl())))
+}
+
+// mathEnabled reports whether math is parsed for the page being parsed.
+func mathEnabled(pc parser.Context) bool {
+	ctx, ok := pc.Get(pageContextKey).(*pageContext)
+	return !ok || ctx.math
+}
+
+func (m *mathExtension) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&displayMathParser{}, 160)),
+		parser.WithInlineParsers(util.Prioritized(&inlineMathParser{}, 150)),
+	)
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(m, 100)))
+}
+
+func (m *mathExtension) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindInlineMath, m.renderInlineMath)
+	reg.Register(KindDisplayMath, m.renderDisplayMath)
+}
+
+func (m *mathExtension) renderInlineMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*InlineMath)
+	if n.Display {
+		_, _ = w.WriteString(`<span class="math display">\[` + html.EscapeString(string(n.Value)) + `\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math inline">\(` + html.EscapeString(string(n.Value)) + `\)</span>`)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (m *mathExtension) renderDisplayMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var tex bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		tex.Write(seg.Value(source))
+	}
+	_, _ = w.WriteString(`<div class="math display">\[` + html.EscapeString(string(bytes.TrimSpace(tex.Bytes()))) + "\\]</div>\n")
+
+	return ast.WalkSkipChildren, nil
+}
+
+func (p *inlineMathParser) Trigger() []byte {
+	return []byte{mathDelimiter}
+}
+
+// Parse parses `$...$` and `$$...$$` within a line. As in pandoc, the opening `$` must not be followed and the closing
+// `$` not be preceded by whitespace, and the closing `$` not be followed by a digit, so that amounts ($5 and $6) are
+// left as they are. Escaped dollars (`\$`) do not delimit math.
+func (p *inlineMathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !mathEnabled(pc) {
+		return nil
+	}
+
+	delimiter := 1
+	if len(line) > 1 && line[1] == mathDelimiter {
+		delimiter = 2
+	}
+	if len(line) <= delimiter || util.IsSpace(line[delimiter]) {
+		return nil
+	}
+
+	for i := delimiter; i < len(line); i++ {
+		switch {
+		case line[i] == '\\':
+			i++
+		case line[i] == mathDelimiter:
+			end := i + delimiter
+			if end > len(line) || !bytes.Equal(line[i:end], bytes.Repeat([]byte{mathDelimiter}, delimiter)) {
+				return nil
+			}
+			if util.IsSpace(line[i-1]) || (end < len(line) && (line[end] == mathDelimiter || (delimiter == 1 && isDigit(line[end])))) {
+				continue
+			}
+			if i == delimiter {
+				return nil
+			}
+
+			value := append([]byte{}, line[delimiter:i]...)
+			block.Advance(end)
+			return &InlineMath{Display: delimiter == 2, Value: value}
+		}
+	}
+
+	return nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (p *displayMathParser) Trigger() []byte {
+	return []byte{mathDelimiter}
+}
+
+// Open opens display math on lines starting with `$$`. A line also ending with `$$` holds all of the math; lines
+// having `$$` elsewhere are left to the inline parser.
+func (p *displayMathParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	width, pos := util.IndentWidth(line, reader.LineOffset())
+	if width >= 4 || !mathEnabled(pc) || !bytes.HasPrefix(line[pos:], displayMathFence) {
+		return nil, parser.NoChildren
+	}
+
+	rest := util.TrimRightSpace(line[pos+len(displayMathFence):])
+	// line includes the padding of a partially consumed tab, which the segment does not
+	start := segment.Start - segment.Padding + pos + len(displayMathFence)
+	node := &DisplayMath{}
+	if i := bytes.Index(rest, displayMathFence); i >= 0 {
+		if i != len(rest)-len(displayMathFence) || i == 0 {
+			return nil, parser.NoChildren
+		}
+		node.Lines().Append(text.NewSegment(start, start+i))
+		node.closed = true
+		advanceLine(reader, line, segment)
+		return node, parser.NoChildren
+	}
+
+	if len(bytes.TrimSpace(rest)) > 0 {
+		node.Lines().Append(text.NewSegment(start, segment.Stop))
+	}
+	advanceLine(reader, line, segment)
+
+	return node, parser.NoChildren
+}
+
+func (p *displayMathParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if line == nil || node.(*DisplayMath).closed {
+		return parser.Close
+	}
+
+	trimmed := util.TrimRightSpace(line)
+	if bytes.HasSuffix(trimmed, displayMathFence) {
+		node.Lines().Append(text.NewSegment(segment.Start, segment.Start-segment.Padding+len(trimmed)-len(displayMathFence)))
+		advanceLine(reader, line, segment)
+		return parser.Close
+	}
+
+	node.Lines().Append(segment)
+	advanceLine(reader, line, segment)
+
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *displayMathParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *displayMathParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *displayMathParser) CanAcceptIndentedLine() bool {
+	return false
+}
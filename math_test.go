@@ -0,0 +1,80 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"testing"
+)
+
+func TestMathExtension(t *testing.T) {
+	render := func(source string, ctx *pageContext) string {
+		gm := goldmark.New(goldmark.WithExtensions(&mathExtension{}))
+		pc := parser.NewContext()
+		if ctx != nil {
+			pc.Set(pageContextKey, ctx)
+		}
+		var out bytes.Buffer
+		doc := gm.Parser().Parse(text.NewReader([]byte(source)), parser.WithContext(pc))
+		if err := gm.Renderer().Render(&out, []byte(source), doc); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{"inline", "Energy $E = mc^2$ holds.", `<p>Energy <span class="math inline">\(E = mc^2\)</span> holds.</p>` + "\n"},
+		{"inline display", "See $$\\sum_i x_i$$ here.", `<p>See <span class="math display">\[\sum_i x_i\]</span> here.</p>` + "\n"},
+		{"escaped html", "Where $a<b$.", `<p>Where <span class="math inline">\(a&lt;b\)</span>.</p>` + "\n"},
+		{"amounts", "It costs $5 and $6.", "<p>It costs $5 and $6.</p>\n"},
+		{"closing before digit", "From $1$2 on.", "<p>From $1$2 on.</p>\n"},
+		{"space after opening", "A $ x$ b", "<p>A $ x$ b</p>\n"},
+		{"escaped dollar", "Price \\$5 and $x$.", `<p>Price $5 and <span class="math inline">\(x\)</span>.</p>` + "\n"},
+		{"escaped delimiter", "$a \\$ b$", `<p><span class="math inline">\(a \$ b\)</span></p>` + "\n"},
+		{"code span", "Use `$x$` literally.", "<p>Use <code>$x$</code> literally.</p>\n"},
+		{"code block", "```\n$$\nx\n$$\n```\n", "<pre><code>$$\nx\n$$\n</code></pre>\n"},
+		{"display block", "$$\n\\int_0^1 x\\,dx\n= \\frac{1}{2}\n$$\n", `<div class="math display">\[\int_0^1 x\,dx` + "\n" + `= \frac{1}{2}\]</div>` + "\n"},
+		{"display line", "Text\n$$ a^2 + b^2 = c^2 $$\nMore", "<p>Text</p>\n" + `<div class="math display">\[a^2 + b^2 = c^2\]</div>` + "\n<p>More</p>\n"},
+		{"display block at end of file", "$$\na\n$$", `<div class="math display">\[a\]</div>` + "\n"},
+		{"display line at end of file", "$$ a $$", `<div class="math display">\[a\]</div>` + "\n"},
+		{"indented display block", "  $$\n  a\n  $$\n", `<div class="math display">\[a\]</div>` + "\n"},
+		{"indented code block", "    $$\n    a\n    $$\n", "<pre><code>$$\na\n$$\n</code></pre>\n"},
+		{"display block in blockquote", ">\t$$\n>\ta\n>\t$$\n", "<blockquote>\n" + `<div class="math display">\[a\]</div>` + "\n</blockquote>\n"},
+		{"display block in list item", "1. x\n\n\t$$\n\ta\n\t$$\n", "<ol>\n<li>\n<p>x</p>\n" + `<div class="math display">\[a\]</div>` + "\n</li>\n</ol>\n"},
+		{"tab indented code block", "\t$$\n\ta\n\t$$\n", "<pre><code>$$\na\n$$\n</code></pre>\n"},
+		{"display fences with content", "$$a\n+ b$$\n", `<div class="math display">\[a` + "\n" + `+ b\]</div>` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, render(tt.source, nil))
+		})
+	}
+
+	t.Run("disabled by page", func(t *testing.T) {
+		ctx := newPageContext(nil, "foo", "1.0", "installation.md")
+		assert.Equal(t, "<p>Energy $E = mc^2$ holds.</p>\n", render("Energy $E = mc^2$ holds.", ctx))
+
+		ctx.math = true
+		assert.Contains(t, render("Energy $E = mc^2$ holds.", ctx), `<span class="math inline">`)
+	})
+}
+
+func TestMathScript(t *testing.T) {
+	assert.Contains(t, MathScript, `<script src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.js" integrity="sha384-`)
+	assert.Contains(t, string(mathScript()), `href="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.css" integrity="sha384-`)
+
+	t.Setenv(EnvKeyMathAssetsUrl, "/assets/katex/")
+	script := string(mathScript())
+	assert.Contains(t, script, `<link rel="stylesheet" href="/assets/katex/katex.min.css" integrity="`+katexCssIntegrity+`" crossorigin="anonymous">`)
+	assert.Contains(t, script, `<script src="/assets/katex/katex.min.js" integrity="`+katexJsIntegrity+`" crossorigin="anonymous">`)
+	assert.NotContains(t, script, "cdn.jsdelivr.net")
+}
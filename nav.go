@@ -0,0 +1,244 @@
+package docweaver
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Nav is the navigation tree of a product version, built from the list structure of its index (documentation.md).
+type Nav struct {
+	Items []*NavItem
+}
+
+// NavItem is a section or link within a Nav.
+type NavItem struct {
+	Title    string
+	Url      string // resolved link, empty for sections without a link
+	PagePath string // path of the linked page within the version, empty for sections and links elsewhere
+	External bool   // link points outside the documentation
+	Active   bool   // item links to the current page
+	Children []*NavItem
+}
+
+// PageRef references a page by title and URL.
+type PageRef struct {
+	Title string
+	Url   string
+}
+
+// HasActive reports whether the item or any of its descendants is active.
+func (i *NavItem) HasActive() bool {
+	if i.Active {
+		return true
+	}
+	for _, c := range i.Children {
+		if c.HasActive() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSection reports whether the item groups other items.
+func (i *NavItem) IsSection() bool {
+	return len(i.Children) > 0
+}
+
+// withActive returns a copy of the nav with items linking to the given page path marked active.
+func (n *Nav) withActive(pagePath string) *Nav {
+	if n == nil {
+		return nil
+	}
+
+	return &Nav{Items: copyNavItems(n.Items, normalizeNavPagePath(pagePath))}
+}
+
+func copyNavItems(items []*NavItem, activePagePath string) []*NavItem {
+	var copies []*NavItem
+	for _, item := range items {
+		c := *item
+		c.Active = c.PagePath != "" && normalizeNavPagePath(c.PagePath) == activePagePath
+		c.Children = copyNavItems(item.Children, activePagePath)
+		copies = append(copies, &c)
+	}
+
+	return copies
+}
+
+// pages returns the items linking to pages of the version in nav order, without duplicates.
+func (n *Nav) pages() (pages []*NavItem) {
+	seen := map[string]bool{}
+	var walk func(items []*NavItem)
+	walk = func(items []*NavItem) {
+		for _, item := range items {
+			if path := normalizeNavPagePath(item.PagePath); item.PagePath != "" && !seen[path] {
+				seen[path] = true
+				pages = append(pages, item)
+			}
+			walk(item.Children)
+		}
+	}
+	if n != nil {
+		walk(n.Items)
+	}
+
+	return
+}
+
+// adjacent returns references to the pages before and after the given page path in nav order.
+func (n *Nav) adjacent(pagePath string) (prev, next *PageRef) {
+	pages := n.pages()
+	pagePath = normalizeNavPagePath(pagePath)
+	for i, item := range pages {
+		if normalizeNavPagePath(item.PagePath) != pagePath {
+			continue
+		}
+		if i > 0 {
+			prev = &PageRef{Title: pages[i-1].Title, Url: pages[i-1].Url}
+		}
+		if i < len(pages)-1 {
+			next = &PageRef{Title: pages[i+1].Title, Url: pages[i+1].Url}
+		}
+		break
+	}
+
+	return
+}
+
+// trail returns the items leading to (excluding) the first item linking to the given page path.
+func (n *Nav) trail(pagePath string) []*NavItem {
+	pagePath = normalizeNavPagePath(pagePath)
+	var find func(items []*NavItem, parents []*NavItem) []*NavItem
+	find = func(items []*NavItem, parents []*NavItem) []*NavItem {
+		for _, item := range items {
+			if item.PagePath != "" && normalizeNavPagePath(item.PagePath) == pagePath {
+				return parents
+			}
+			if t := find(item.Children, append(parents[:len(parents):len(parents)], item)); t != nil {
+				return t
+			}
+		}
+		return nil
+	}
+	if n == nil {
+		return nil
+	}
+
+	return find(n.Items, []*NavItem{})
+}
+
+// breadcrumbs returns the trail from the page's product through the nav sections to the page itself.
+func breadcrumbs(page *Page) []PageRef {
+	crumbs := []PageRef{{Title: page.Product.Name, Url: fmt.Sprintf("%s/%s", page.Product.BaseUrl, page.Version)}}
+	for _, item := range page.Nav.trail(page.UrlPath) {
+		crumbs = append(crumbs, PageRef{Title: item.Title, Url: item.Url})
+	}
+
+	return append(crumbs, PageRef{Title: page.Title, Url: page.Url()})
+}
+
+// buildNav builds a nav from the top level lists of a parsed index document.
+func buildNav(doc ast.Node, source []byte, productKey, version string) *Nav {
+	nav := &Nav{}
+	for c := doc.FirstChild(); c != nil; c = c.NextSibling() {
+		if list, ok := c.(*ast.List); ok {
+			nav.Items = append(nav.Items, buildNavItems(list, source, productKey, version)...)
+		}
+	}
+
+	return nav
+}
+
+func buildNavItems(list *ast.List, source []byte, productKey, version string) []*NavItem {
+	var items []*NavItem
+	for li := list.FirstChild(); li != nil; li = li.NextSibling() {
+		item := &NavItem{}
+		for c := li.FirstChild(); c != nil; c = c.NextSibling() {
+			if sub, ok := c.(*ast.List); ok {
+				item.Children = append(item.Children, buildNavItems(sub, source, productKey, version)...)
+				continue
+			}
+			if item.Title != "" {
+				continue
+			}
+
+			item.Title = strings.TrimSpace(nodeText(c, source))
+			if link := findLink(c); link != nil {
+				item.Url, item.PagePath, item.External = resolveNavLink(string(link.Destination), productKey, version)
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// resolveNavLink resolves a link destination found in the index to a URL. The page path is returned for links to
+// pages of the same product version.
+func resolveNavLink(destination, productKey, version string) (link, pagePath string, external bool) {
+	link = replaceLinkDestination(productKey, version, destination)
+	u, err := url.Parse(link)
+	if err != nil {
+		return link, "", false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return link, "", true
+	}
+
+	versionPrefix := fmt.Sprintf("%s/%s/%s/", normalizeRoutePrefix(GetRoutePrefix()), productKey, version)
+	if strings.HasPrefix(u.Path, versionPrefix) {
+		pagePath = strings.Trim(strings.TrimPrefix(u.Path, versionPrefix), "/")
+	}
+
+	return link, pagePath, false
+}
+
+// normalizeNavPagePath maps the page path of a directory index to the directory's page path.
+func normalizeNavPagePath(pagePath string) string {
+	pagePath = strings.Trim(pagePath, "/")
+	if pagePath == dirIndexPagePath {
+		return ""
+	}
+
+	return strings.TrimSuffix(pagePath, "/"+dirIndexPagePath)
+}
+
+// findLink returns the first link within node, if any.
+func findLink(node ast.Node) (link *ast.Link) {
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if l, ok := n.(*ast.Link); ok && entering {
+			link = l
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return
+}
+
+// nodeText returns the plain text content of a node.
+func nodeText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch t := n.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return b.String()
+}
@@ -0,0 +1,91 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/text"
+	"testing"
+)
+
+func TestBuildNav(t *testing.T) {
+	source := []byte(`- ## Getting Started
+    - [Installation](/docs/{{version}}/installation)
+    - [Deploy]({{docs}}/guides/deploy#steps)
+- Reference
+    - [Other product](/docs/other/1.0/installation)
+- [Website](https://example.com)
+`)
+	doc := goldmark.New().Parser().Parse(text.NewReader(source))
+	nav := buildNav(doc, source, "prod", "1.0")
+
+	if !assert.Len(t, nav.Items, 3) {
+		return
+	}
+	assert.Equal(t, "Getting Started", nav.Items[0].Title)
+	assert.True(t, nav.Items[0].IsSection())
+	assert.Equal(t, &NavItem{Title: "Installation", Url: "/docs/prod/1.0/installation", PagePath: "installation"}, nav.Items[0].Children[0])
+	assert.Equal(t, "/docs/prod/1.0/guides/deploy#steps", nav.Items[0].Children[1].Url)
+	assert.Equal(t, "guides/deploy", nav.Items[0].Children[1].PagePath)
+	assert.Equal(t, "Reference", nav.Items[1].Title)
+	assert.Equal(t, "", nav.Items[1].Children[0].PagePath)
+	assert.False(t, nav.Items[1].Children[0].External)
+	assert.Equal(t, &NavItem{Title: "Website", Url: "https://example.com", External: true}, nav.Items[2])
+}
+
+func TestNav_WithActive(t *testing.T) {
+	nav := &Nav{Items: []*NavItem{
+		{Title: "Guides", Children: []*NavItem{
+			{Title: "Overview", PagePath: "guides"},
+			{Title: "Deploy", PagePath: "guides/deploy"},
+		}},
+		{Title: "Support", PagePath: "support"},
+	}}
+
+	active := nav.withActive("guides/deploy")
+	assert.True(t, active.Items[0].HasActive())
+	assert.True(t, active.Items[0].Children[1].Active)
+	assert.False(t, active.Items[0].Children[0].Active)
+	assert.False(t, active.Items[1].HasActive())
+	assert.False(t, nav.Items[0].Children[1].Active, "original nav must not be modified")
+
+	assert.True(t, nav.withActive("guides/index").Items[0].Children[0].Active)
+}
+
+func TestNav_Adjacent(t *testing.T) {
+	nav := &Nav{Items: []*NavItem{
+		{Title: "Getting Started", Children: []*NavItem{
+			{Title: "Installation", Url: "/i", PagePath: "installation"},
+			{Title: "Installation (again)", Url: "/i", PagePath: "installation"},
+		}},
+		{Title: "Guides", Url: "/g", PagePath: "guides", Children: []*NavItem{
+			{Title: "Deploy", Url: "/g/d", PagePath: "guides/deploy"},
+		}},
+		{Title: "Website", Url: "https://example.com", External: true},
+	}}
+
+	testData := []struct {
+		pagePath     string
+		expectedPrev *PageRef
+		expectedNext *PageRef
+	}{
+		{"installation", nil, &PageRef{Title: "Guides", Url: "/g"}},
+		{"guides/index", &PageRef{Title: "Installation", Url: "/i"}, &PageRef{Title: "Deploy", Url: "/g/d"}},
+		{"guides/deploy", &PageRef{Title: "Guides", Url: "/g"}, nil},
+		{"unknown", nil, nil},
+	}
+
+	for _, td := range testData {
+		t.Run(td.pagePath, func(t *testing.T) {
+			prev, next := nav.adjacent(td.pagePath)
+
+			assert.Equal(t, td.expectedPrev, prev)
+			assert.Equal(t, td.expectedNext, next)
+		})
+	}
+
+	assert.Equal(t, []*NavItem{nav.Items[1]}, nav.trail("guides/deploy"))
+	assert.Empty(t, nav.trail("guides"))
+	assert.Nil(t, nav.trail("unknown"))
+}
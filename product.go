@@ -1,11 +1,21 @@
 package docweaver
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/reliqarts/go-common"
 	yml "gopkg.in/yaml.v3"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Product struct {
@@ -17,15 +27,31 @@ type Product struct {
 	LatestVersion string
 	Index         *Page
 	root          productRoot
+	meta          productMeta
 }
 
 type Page struct {
-	UrlPath string
-	Title   string
-	Content string
-	Version string
-	Product *Product
-	Index   *Page
+	UrlPath      string
+	Title        string
+	Content      string
+	Version      string
+	Product      *Product
+	Index        *Page
+	Hash         string    // hex encoded SHA-256 hash of the page source, rendered content and product state
+	LastModified time.Time // commit time of published version or modification time of source file
+	Description  string
+	Keywords     []string
+	Order        int
+	Draft        bool
+	Aliases      []string
+	Layout       string
+	Meta         map[string]interface{} // all front matter fields, including custom ones
+	Nav          *Nav                   // navigation tree built from the index
+	Prev         *PageRef               // previous page in nav order
+	Next         *PageRef               // next page in nav order
+	Breadcrumbs  []PageRef              // trail from the product through the nav sections to the page
+	TOC          []*TocEntry            // table of contents built from the page's headings
+	Diagnostics  []Diagnostic           // problems found while rendering the page, e.g. failed includes
 }
 
 type productRoot struct {
@@ -35,9 +61,15 @@ type productRoot struct {
 }
 
 type productMeta struct {
-	Name        string
-	Description string
-	ImageUrl    string `yaml:"image_url"`
+	Name            string
+	Description     string
+	ImageUrl        string            `yaml:"image_url"`
+	Highlight       highlightConfig   `yaml:"highlight"`
+	Variables       map[string]string `yaml:"variables"`
+	Sanitize        bool              `yaml:"sanitize"`
+	Math            bool              `yaml:"math"`
+	VersionFallback string            `yaml:"version_fallback"`
+	Partials        []string          `yaml:"partials"`
 }
 
 func (p *productRoot) filePath() string {
@@ -48,6 +80,225 @@ func (p *productRoot) versionFilePath(version string) string {
 	return fmt.Sprintf("%s%c%s", p.filePath(), os.PathSeparator, version)
 }
 
+// pageFilePath returns the path of the markdown file for a (slash separated) page path within the given version.
+// A page path naming a directory resolves to that directory's index page.
+func (p *productRoot) pageFilePath(version, pagePath string) string {
+	base := fmt.Sprintf("%s%c%s", p.versionFilePath(version), os.PathSeparator, filepath.FromSlash(pagePath))
+	filePath := fmt.Sprintf("%s.%s", base, pageExt)
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if fi, err := os.Stat(base); err == nil && fi.IsDir() {
+			return fmt.Sprintf("%s%c%s.%s", base, os.PathSeparator, dirIndexPagePath, pageExt)
+		}
+	}
+
+	return filePath
+}
+
+// versionFingerprint returns a hash identifying the current state of the files within the given version.
+func (p *productRoot) versionFingerprint(version string) (string, error) {
+	root := p.versionFilePath(version)
+	h := sha256.New()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(h, "%s:%d:%d\n", filepath.ToSlash(rel), fi.Size(), fi.ModTime().UnixNano())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// versionHistory is the git history of a published (cloned) version, read once per checked out commit.
+type versionHistory struct {
+	head       string // hash of the checked out commit
+	commit     string // abbreviated hash of the checked out commit
+	commitTime time.Time
+	files      map[string]fileTimes // by slash separated path relative to the version directory
+}
+
+// fileTimes are the times a file was first and last committed.
+type fileTimes struct {
+	created  time.Time
+	modified time.Time
+}
+
+// versionHistories caches the history of published versions by version directory.
+var versionHistories = struct {
+	sync.Mutex
+	entries map[string]*versionHistory
+}{entries: map[string]*versionHistory{}}
+
+// history returns the git history of the given version. It is read with a single `git log` and cached until another
+// commit is checked out. Only published (cloned) versions have a history.
+func (p *productRoot) history(version string) (*versionHistory, error) {
+	verPath := p.versionFilePath(version)
+	head, err := gitHead(verPath)
+	if err != nil {
+		return nil, err
+	}
+
+	versionHistories.Lock()
+	h, ok := versionHistories.entries[verPath]
+	versionHistories.Unlock()
+	if ok && h.head == head {
+		return h, nil
+	}
+
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "log", "--format=>%ct %h", "--name-only", "--no-renames")
+	cmd.Dir = verPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	h = &versionHistory{head: head, files: map[string]fileTimes{}}
+	var committed time.Time
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, ">") {
+			fields := strings.Fields(line[1:])
+			if len(fields) != 2 {
+				continue
+			}
+			ts, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			committed = time.Unix(ts, 0).UTC()
+			if h.commit == "" {
+				h.commit, h.commitTime = fields[1], committed
+			}
+			continue
+		}
+		if line == "" || committed.IsZero() {
+			continue
+		}
+
+		// commits are listed newest first
+		ft, seen := h.files[line]
+		if !seen {
+			ft.modified = committed
+		}
+		ft.created = committed
+		h.files[line] = ft
+	}
+	if h.commit == "" {
+		return nil, simpleError{fmt.Sprintf("No commits found in `%s`.", verPath)}
+	}
+
+	versionHistories.Lock()
+	versionHistories.entries[verPath] = h
+	versionHistories.Unlock()
+
+	return h, nil
+}
+
+// gitHead returns the hash of the commit checked out in the git repository at [dir], read from its `.git` directory.
+func gitHead(dir string) (string, error) {
+	gitDir := filepath.Join(dir, ".git")
+	b, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+
+	head := strings.TrimSpace(string(b))
+	ref := strings.TrimPrefix(head, "ref: ")
+	if ref == head {
+		return head, nil
+	}
+	if b, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	packed, err := os.ReadFile(filepath.Join(gitDir, "packed-refs"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(packed), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+
+	return "", simpleError{fmt.Sprintf("Failed to resolve `%s` in `%s`.", ref, gitDir)}
+}
+
+// commitTime returns the time of the commit checked out for the given version. Only published (cloned) versions
+// have a commit time.
+func (p *productRoot) commitTime(version string) (time.Time, error) {
+	h, err := p.history(version)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return h.commitTime, nil
+}
+
+// commit returns the abbreviated hash of the commit checked out for the given version. Only published (cloned)
+// versions have a commit.
+func (p *productRoot) commit(version string) (string, error) {
+	h, err := p.history(version)
+	if err != nil {
+		return "", err
+	}
+
+	return h.commit, nil
+}
+
+// fileHistory returns the times a file within the given version was first and last committed. The file's modification
+// time is returned for both if the version is not published (cloned) or the file is not committed.
+func (p *productRoot) fileHistory(version, filePath string) (created, modified time.Time) {
+	verPath := p.versionFilePath(version)
+	if h, err := p.history(version); err == nil {
+		if rel, err := filepath.Rel(verPath, filePath); err == nil {
+			if ft, ok := h.files[filepath.ToSlash(rel)]; ok {
+				return ft.created, ft.modified
+			}
+		}
+	}
+
+	if fi, err := os.Stat(filePath); err == nil {
+		created, modified = fi.ModTime().UTC(), fi.ModTime().UTC()
+	}
+
+	return
+}
+
+// lastModified returns the last modification time of a file within the given version. The commit time is preferred
+// for published versions, the file's modification time is used otherwise.
+func (p *productRoot) lastModified(version, filePath string) time.Time {
+	if ct, err := p.commitTime(version); err == nil {
+		return ct
+	}
+
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return fi.ModTime().UTC()
+}
+
 func (p *productRoot) hasSource() bool {
 	return p.Source != ""
 }
@@ -60,6 +311,87 @@ func (p *Product) Url() string {
 	return fmt.Sprintf("%s/%s", p.BaseUrl, p.LatestVersion)
 }
 
+// currentVersion returns the latest version, or the default version if the product has no versions besides its main
+// versions.
+func (p *Product) currentVersion() string {
+	if p.LatestVersion == versionNone {
+		return defaultVersion
+	}
+	return p.LatestVersion
+}
+
+// HighlightCssUrl returns the URL of the stylesheet for syntax highlighted code blocks of the product, or an empty
+// string if highlighting is disabled.
+func (p *Product) HighlightCssUrl() string {
+	if !p.meta.Highlight.enabled() {
+		return ""
+	}
+	return highlightCssUrl(p.meta.Highlight.theme())
+}
+
+// MathEnabled returns whether math is rendered in the product's pages.
+func (p *Product) MathEnabled() bool {
+	return p.meta.Math
+}
+
+// sanitize returns whether the content of the product's pages is sanitized. The meta file comes from the (possibly
+// untrusted) doc source, so it may only enable sanitization; exemptions are configured via DW_SANITIZE_EXEMPT.
+func (p *Product) sanitize() bool {
+	if p.meta.Sanitize {
+		return true
+	}
+	return GetSanitize() && !containsString(GetSanitizeExempt(), p.Key())
+}
+
+// pageFingerprint returns a hash of the product-level inputs pages of the given version depend on beyond their own
+// source: the versions, latest version and meta file of the product, and the files of the version, including its index.
+func (p *Product) pageFingerprint(version string) (string, error) {
+	meta, err := json.Marshal(p.meta)
+	if err != nil {
+		return "", err
+	}
+	fingerprint, err := p.root.versionFingerprint(version)
+	if err != nil {
+		return "", err
+	}
+
+	return contentHash(fmt.Sprintf("%s:%s:%s:%s", strings.Join(p.Versions, ","), p.LatestVersion, meta, fingerprint)), nil
+}
+
+// partialsDirs returns the slash separated paths of the directories, relative to a version root, holding included
+// files rather than pages. Unless configured otherwise in the meta file, this is the `partials` directory.
+func (p *Product) partialsDirs() []string {
+	if p.meta.Partials == nil {
+		return []string{partialsDirName}
+	}
+
+	dirs := make([]string, 0, len(p.meta.Partials))
+	for _, dir := range p.meta.Partials {
+		if dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/"); dir != "" && dir != "." {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// versionFallback returns how pages missing in a requested version of the product are handled.
+func (p *Product) versionFallback() string {
+	switch fallback := p.meta.VersionFallback; fallback {
+	case VersionFallbackSuggest, VersionFallbackRedirect:
+		return fallback
+	case "":
+		return GetVersionFallback()
+	default:
+		log(lWarn, "Invalid version fallback `%s` configured for product `%s`.\n", fallback, p.root.Key)
+		return GetVersionFallback()
+	}
+}
+
+// Url returns the URL of the page.
+func (p *Page) Url() string {
+	return fmt.Sprintf("%s/%s/%s", p.Product.BaseUrl, p.Version, p.UrlPath)
+}
+
 func (p *Product) loadMeta() {
 	var err error
 	var meta *productMeta
@@ -79,6 +411,7 @@ func (p *Product) loadMeta() {
 	}
 
 	if meta != nil {
+		p.meta = *meta
 		if meta.Name != "" {
 			p.Name = meta.Name
 		}
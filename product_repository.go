@@ -2,16 +2,15 @@ package docweaver
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
-	"github.com/yuin/goldmark"
-	emoji "github.com/yuin/goldmark-emoji"
-	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/renderer/html"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -30,21 +29,38 @@ type ProductRepository interface {
 	ListProductKeys() ([]string, error)
 }
 
+// PageLister is implemented by product repositories able to list the pages of a product version. Search, sitemaps,
+// feeds, static export and link checking require it of their repository.
+type PageLister interface {
+	// ListPages lists the page paths of all pages within a product version, excluding the index and the partials
+	// directories configured for the product.
+	ListPages(productKey, version string) ([]string, error)
+}
+
 type productRepository struct {
-	dir string
+	dir      string
+	renderer Renderer
 }
 
 const (
 	defaultPagePath = "installation"
 	pageExt         = "md"
 	indexPath       = "documentation"
+	// dirIndexPagePath is the page served when a page path names a directory.
+	dirIndexPagePath = "index"
 )
 
 func GetRepository(dir string) ProductRepository {
+	return GetRepositoryWithRenderer(dir, nil)
+}
+
+// GetRepositoryWithRenderer returns a ProductRepository for [dir] rendering pages with [renderer]. The default
+// Renderer is used if none is provided.
+func GetRepositoryWithRenderer(dir string, renderer Renderer) ProductRepository {
 	if dir == "" {
 		dir = getDocsDir()
 	}
-	return &productRepository{dir: dir}
+	return &productRepository{dir: dir, renderer: renderer}
 }
 
 func (pr *productRepository) GetDir() string {
@@ -96,7 +112,67 @@ func (pr *productRepository) ListProductKeys() ([]string, error) {
 	return productNames, nil
 }
 
+func (pr *productRepository) ListPages(productKey, version string) ([]string, error) {
+	if err := validateProductKey(productKey); err != nil {
+		return nil, err
+	}
+	if err := validateVersion(version); err != nil {
+		return nil, err
+	}
+
+	p, err := pr.FindProduct(productKey)
+	if err != nil {
+		return nil, err
+	}
+	root := p.root.versionFilePath(version)
+	partialsDirs := p.partialsDirs()
+	var pagePaths []string
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(d.Name(), ".") || (d.IsDir() && containsString(partialsDirs, rel)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(path) != "."+pageExt {
+			return nil
+		}
+
+		pagePath := strings.TrimSuffix(rel, "."+pageExt)
+		if pagePath == indexPath {
+			return nil
+		}
+		if strings.HasSuffix(pagePath, "/"+dirIndexPagePath) {
+			pagePath = strings.TrimSuffix(pagePath, "/"+dirIndexPagePath)
+		}
+		pagePaths = append(pagePaths, pagePath)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pagePaths, nil
+}
+
 func (pr *productRepository) FindProduct(productKey string) (*Product, error) {
+	if err := validateProductKey(productKey); err != nil {
+		return nil, err
+	}
+
 	r := productRoot{ParentDir: pr.dir, Key: productKey}
 	var versions []string
 
@@ -128,53 +204,157 @@ func (pr *productRepository) GetPage(productKey, version, pagePath string) (*Pag
 		log(lInfo, "Using default version (%s) for product `%s`, page path: `%s`.\n", defaultVersion, productKey, pagePath)
 		version = defaultVersion
 	}
+	pagePath = strings.TrimRight(pagePath, "/")
 	if pagePath == "" {
 		log(lInfo, "Using default page path (%s) for product `%s`, version: `%s`.\n", defaultPagePath, productKey, version)
 		pagePath = defaultPagePath
 	}
 
+	if err := validateProductKey(productKey); err != nil {
+		return nil, err
+	}
+	if err := validateVersion(version); err != nil {
+		return nil, err
+	}
+	if err := validatePagePath(pagePath); err != nil {
+		return nil, err
+	}
+
 	r := productRoot{ParentDir: pr.dir, Key: productKey}
 	p, err := pr.FindProduct(productKey)
 	if err != nil {
 		return nil, simpleError{fmt.Sprintf("Failed to init product for page. %s", err)}
 	}
 
-	filePath := fmt.Sprintf("%s%c%s.%s", r.versionFilePath(version), os.PathSeparator, pagePath, pageExt)
-	md, err := os.ReadFile(filePath)
+	filePath := r.pageFilePath(version, pagePath)
+	resolvedFilePath, err := confinePath(pr.dir, filePath)
+	if err != nil {
+		log(lWarn, "Failed to resolve product page file path `%s`. %s\n", filePath, err)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, newPageNotFoundError(p, version, pagePath, err)
+		}
+		return nil, err
+	}
+
+	// the resolved path is read, so that a symlink swapped after confinement cannot lead outside the docs directory
+	md, err := os.ReadFile(resolvedFilePath)
 	if err != nil {
 		log(lWarn, "Failed to read product page from file path `%s`.\n", filePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, newPageNotFoundError(p, version, pagePath, err)
+		}
 		return nil, err
 	}
 
-	var rawContent bytes.Buffer
-	err = goldmark.New(
-		goldmark.WithExtensions(extension.GFM, emoji.Emoji),
-		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
-		goldmark.WithRendererOptions(html.WithHardWraps(), html.WithXHTML(), html.WithUnsafe()),
-	).Convert(md, &rawContent)
+	fm, meta, body, err := parseFrontMatter(md)
+	if err != nil {
+		log(lWarn, "Failed to parse front matter of product page from file path `%s`. %s\n", filePath, err)
+	}
+	body, diagnostics := expandDirectives(r.versionFilePath(version), resolvedFilePath, body)
+	body, variableDiagnostics := substituteVariables(body, pageVariables(p, version))
+	diagnostics = append(diagnostics, variableDiagnostics...)
+
+	// relative links resolve against the file read, unless it lies outside of the version
+	relFilePath, err := filepath.Rel(r.versionFilePath(version), filePath)
 	if err != nil {
 		return nil, err
 	}
+	if versionDir, err := filepath.EvalSymlinks(r.versionFilePath(version)); err == nil && isWithin(versionDir, resolvedFilePath) {
+		if relFilePath, err = filepath.Rel(versionDir, resolvedFilePath); err != nil {
+			return nil, err
+		}
+	}
+	pageCtx := newPageContext(pr, productKey, version, filepath.ToSlash(relFilePath))
+	pageCtx.highlight = p.meta.Highlight
+	pageCtx.math = p.meta.Math
+	pc := parser.NewContext()
+	pc.Set(pageContextKey, pageCtx)
+	doc := pr.getRenderer().Parse(body, pc)
+	diagnostics = append(diagnostics, pageCtx.diagnostics...)
+	for _, d := range diagnostics {
+		log(lWarn, "Product page `%s`: %s\n", filePath, d)
+	}
+
+	var rawContent bytes.Buffer
+	if err = pr.getRenderer().Render(&rawContent, body, doc); err != nil {
+		return nil, err
+	}
 
 	content := replaceLinks(productKey, version, rawContent.String())
+	if p.sanitize() {
+		if content, err = newSanitizer().sanitize(content); err != nil {
+			return nil, err
+		}
+	}
 
 	var index *Page = nil
+	var nav *Nav
 	if pagePath != indexPath {
 		index, err = pr.GetPage(r.Key, version, indexPath)
 		if err != nil {
 			log(lWarn, "Failed to read product index for page (%s) from path `%s`.\n", pagePath, indexPath)
 			index = nil
+		} else {
+			nav = index.Nav.withActive(pagePath)
 		}
+	} else {
+		nav = buildNav(doc, body, productKey, version)
+	}
+
+	// the product state is part of the hash, so that changes to the index, versions or meta file invalidate the page
+	fingerprint, err := p.pageFingerprint(version)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		fingerprint += index.Hash
 	}
 
-	return &Page{
-		UrlPath: pagePath,
-		Title:   getPageTitleFromHtml(content),
-		Content: content,
-		Product: p,
-		Version: version,
-		Index:   index,
-	}, nil
+	title := fm.Title
+	if title == "" {
+		title = getPageTitleFromHtml(content)
+	}
+
+	page := &Page{
+		UrlPath:      pagePath,
+		Title:        title,
+		Content:      content,
+		Product:      p,
+		Version:      version,
+		Index:        index,
+		Hash:         contentHash(string(md) + content + fingerprint),
+		LastModified: r.lastModified(version, resolvedFilePath),
+		Description:  fm.Description,
+		Keywords:     fm.Keywords,
+		Order:        fm.Order,
+		Draft:        fm.Draft,
+		Aliases:      fm.Aliases,
+		Layout:       fm.Layout,
+		Meta:         meta,
+		Nav:          nav,
+		TOC:          buildToc(doc, body, GetTocMinLevel(), GetTocMaxLevel()),
+		Diagnostics:  diagnostics,
+	}
+	page.Prev, page.Next = nav.adjacent(pagePath)
+	page.Breadcrumbs = breadcrumbs(page)
+
+	return page, nil
+}
+
+// listPages lists the pages of a product version of [repo], which must implement PageLister.
+func listPages(repo ProductRepository, productKey, version string) ([]string, error) {
+	lister, ok := repo.(PageLister)
+	if !ok {
+		return nil, simpleError{"Product repository does not implement PageLister."}
+	}
+	return lister.ListPages(productKey, version)
+}
+
+func (pr *productRepository) getRenderer() Renderer {
+	if pr.renderer == nil {
+		return defaultRenderer
+	}
+	return pr.renderer
 }
 
 func (pr *productRepository) GetIndex(productName string) (*Page, error) {
@@ -200,7 +380,7 @@ func (pr *productRepository) newProduct(r productRoot, versions []string) (produ
 	latestV := latestVersion(versions)
 	product = &Product{
 		Name:          cases.Title(language.English).String(r.Key),
-		BaseUrl:       fmt.Sprintf("%s/%s", GetRoutePrefix(), r.Key),
+		BaseUrl:       fmt.Sprintf("%s/%s", normalizeRoutePrefix(GetRoutePrefix()), r.Key),
 		LatestVersion: latestV,
 		Versions:      versions,
 		root:          r,
@@ -3,9 +3,16 @@
 package docweaver_test
 
 import (
+	"errors"
 	"fmt"
+	cp "github.com/otiai10/copy"
 	"github.com/reliqarts/go-docweaver"
 	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -37,6 +44,8 @@ func TestProductRepository_GetPage(t *testing.T) {
 
 			assert.Equal(t, "Product 1", page.Title)
 			assert.Equal(t, version, page.Version)
+			assert.Len(t, page.Hash, 64)
+			assert.False(t, page.LastModified.IsZero())
 			assert.Contains(t, page.Content, fmt.Sprintf("/docs/%s/%s/", testProductKey, version))
 			assert.Contains(t, page.Content, "href=\"http://iamreliq.com\"")
 			assert.Contains(t, page.Index.Content, fmt.Sprintf("href=\"/docs/%s/%s/support\"", testProductKey, version))
@@ -44,3 +53,469 @@ func TestProductRepository_GetPage(t *testing.T) {
 		})
 	}
 }
+
+func TestProductRepository_GetPage_RejectsInvalidInput(t *testing.T) {
+	testData := []struct {
+		name                      string
+		productKey, version, path string
+		expectedField             string
+	}{
+		{"product traversal", "..", "main", "installation", docweaver.FieldProductKey},
+		{"version traversal", testProductKey, "..", "installation", docweaver.FieldVersion},
+		{"page traversal", testProductKey, "main", "../../../readme", docweaver.FieldPagePath},
+		{"absolute page path", testProductKey, "main", "/etc/passwd", docweaver.FieldPagePath},
+	}
+
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			_, err := repo.GetPage(td.productKey, td.version, td.path)
+
+			var ve docweaver.ValidationError
+			assert.True(t, errors.As(err, &ve))
+			assert.Equal(t, td.expectedField, ve.Field)
+		})
+	}
+}
+
+func TestProductRepository_GetPage_Symlink(t *testing.T) {
+	docs, outside := t.TempDir(), t.TempDir()
+	versionDir := filepath.Join(docs, "linked", "main")
+	if err := os.MkdirAll(filepath.Join(versionDir, "guides"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(versionDir, "guides", "setup.md"): "# Setup\n\n[Deploy](deploy.md)\n",
+		filepath.Join(outside, "secret.md"):             "# Secret\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(versionDir, "setup.md"):  filepath.Join(versionDir, "guides", "setup.md"),
+		filepath.Join(versionDir, "secret.md"): filepath.Join(outside, "secret.md"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := docweaver.GetRepository(docs)
+
+	page, err := r.GetPage("linked", "main", "setup")
+	if assert.NoError(t, err) {
+		assert.Contains(t, page.Content, "href=\"/docs/linked/main/guides/deploy\"", "links resolve against the file read")
+	}
+
+	_, err = r.GetPage("linked", "main", "secret")
+	var ve docweaver.ValidationError
+	assert.ErrorAs(t, err, &ve)
+}
+
+func TestProductRepository_GetPage_Hash(t *testing.T) {
+	docs := t.TempDir()
+	versionDir := filepath.Join(docs, "hashed", "main")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".docweaver.yml", "name: Hashed\n")
+	write("documentation.md", "- [Installation](installation.md)\n")
+	write("installation.md", "# Installation\n")
+	r := docweaver.GetRepository(docs)
+	hash := func() string {
+		page, err := r.GetPage("hashed", "main", "installation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page.Hash
+	}
+
+	previous := hash()
+	assert.Equal(t, previous, hash(), "hash is stable")
+
+	changes := map[string]func(){
+		"index": func() { write("documentation.md", "- [Installation](installation.md)\n- [Guides](guides.md)\n") },
+		"meta":  func() { write(".docweaver.yml", "name: Renamed\n") },
+		"latest version": func() {
+			if err := os.MkdirAll(filepath.Join(docs, "hashed", "1.0"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+	for _, name := range []string{"index", "meta", "latest version"} {
+		changes[name]()
+		current := hash()
+		assert.NotEqual(t, previous, current, name)
+		previous = current
+	}
+}
+
+func TestProductRepository_GetPage_Nested(t *testing.T) {
+	testData := []struct {
+		path          string
+		expectedTitle string
+		expectedLink  string
+	}{
+		{"guides/deploy", "Deploy", fmt.Sprintf("href=\"/docs/%s/main/support\"", testProductKey)},
+		{"guides/advanced/caching", "Caching", fmt.Sprintf("href=\"/docs/%s/main/guides\"", testProductKey)},
+		{"guides", "Guides", fmt.Sprintf("href=\"/docs/%s/main/guides/advanced/caching\"", testProductKey)},
+		{"guides/", "Guides", fmt.Sprintf("href=\"/docs/%s/main/guides/deploy\"", testProductKey)},
+	}
+
+	for _, td := range testData {
+		t.Run(td.path, func(t *testing.T) {
+			page, err := repo.GetPage(testProductKey, "main", td.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, td.expectedTitle, page.Title)
+			assert.Contains(t, page.Content, td.expectedLink)
+			assert.NotNil(t, page.Index)
+		})
+	}
+}
+
+func TestProductRepository_GetPage_FrontMatter(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Configuring Product 1", page.Title)
+	assert.Equal(t, "How to configure product 1.", page.Description)
+	assert.Equal(t, []string{"config", "setup"}, page.Keywords)
+	assert.Equal(t, 2, page.Order)
+	assert.False(t, page.Draft)
+	assert.Equal(t, []string{"setup"}, page.Aliases)
+	assert.Equal(t, "wide", page.Layout)
+	assert.Equal(t, "admins", page.Meta["audience"])
+	assert.NotContains(t, page.Content, "audience")
+	assert.Contains(t, page.Content, "<h1 id=\"configuration\">Configuration</h1>")
+}
+
+func TestProductRepository_GetPage_Nav(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "guides/deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.NotNil(t, page.Nav) || !assert.Len(t, page.Nav.Items, 3) {
+		return
+	}
+	gettingStarted, guides, website := page.Nav.Items[0], page.Nav.Items[1], page.Nav.Items[2]
+	assert.Equal(t, "Getting Started", gettingStarted.Title)
+	assert.False(t, gettingStarted.HasActive())
+	assert.Equal(t, "Guides", guides.Title)
+	assert.True(t, guides.HasActive())
+	assert.True(t, guides.Children[1].Active)
+	assert.Equal(t, fmt.Sprintf("/docs/%s/main/guides/deploy", testProductKey), guides.Children[1].Url)
+	assert.True(t, website.External)
+	assert.False(t, page.Index.Nav.Items[1].Children[1].Active)
+}
+
+func TestProductRepository_GetPage_PrevNextAndBreadcrumbs(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "guides/deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageUrl := func(path string) string {
+		return fmt.Sprintf("/docs/%s/main/%s", testProductKey, path)
+	}
+
+	assert.Equal(t, &docweaver.PageRef{Title: "Overview", Url: pageUrl("guides")}, page.Prev)
+	assert.Equal(t, &docweaver.PageRef{Title: "Caching", Url: pageUrl("guides/advanced/caching")}, page.Next)
+	assert.Equal(t, []docweaver.PageRef{
+		{Title: "Product One", Url: fmt.Sprintf("/docs/%s/main", testProductKey)},
+		{Title: "Guides", Url: ""},
+		{Title: "Deploy", Url: pageUrl("guides/deploy")},
+	}, page.Breadcrumbs)
+
+	first, err := repo.GetPage(testProductKey, "main", "installation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, first.Prev)
+	assert.Equal(t, "Configuration", first.Next.Title)
+}
+
+func TestProductRepository_GetPage_TOC(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.Len(t, page.TOC, 2) {
+		return
+	}
+	assert.Equal(t, "options", page.TOC[0].ID)
+	assert.Equal(t, "environment", page.TOC[0].Children[0].ID)
+	assert.Equal(t, "options-1", page.TOC[1].ID)
+	assert.Contains(t, page.Content, "id=\"options-1\"")
+}
+
+func TestProductRepository_GetPage_Extensions(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "guides/deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, page.Content, "<pre class=\"chroma\"><code class=\"language-go\" data-lang=\"go\">")
+	assert.Contains(t, page.Content, "<span class=\"line hl\">")
+	assert.Contains(t, page.Content, "<aside class=\"admonition admonition-warning\">")
+	assert.Contains(t, page.Content, "<summary class=\"admonition-title\">Rolling deploys</summary>")
+	assert.Contains(t, page.Content, "<div class=\"tabs\" data-tabs-key=\"pkg\" data-tabs-selected=\"npm\">")
+	assert.Contains(t, page.Content, "Deploying...")
+	assert.Contains(t, page.Content, fmt.Sprintf("href=\"/docs/%s/main/guides/advanced/caching#invalidation\"", testProductKey))
+	assert.Contains(t, page.Content, fmt.Sprintf("href=\"/docs/%s/main/configuration#options\"", testProductKey))
+	assert.Contains(t, page.Content, fmt.Sprintf("src=\"%s/%s/main/images/deploy.png\"", docweaver.GetAssetsRoutePrefix(), testProductKey))
+	assert.NotContains(t, page.Content, "region")
+	assert.Equal(t, fmt.Sprintf("%s/_highlight/monokai.css", docweaver.GetAssetsRoutePrefix()), page.Product.HighlightCssUrl())
+}
+
+func TestProductRepository_GetPage_Include(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "installation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, page.Content, "<h2 id=\"requirements\">Requirements</h2>")
+	assert.Contains(t, page.Content, "<li>Git</li>")
+	assert.NotContains(t, page.Content, "include")
+}
+
+func TestProductRepository_GetPage_Variables(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, page.Content, "Requires Go 1.17 and Product One 1.0, served from <code>api.example.com</code>.")
+	assert.Contains(t, page.Content, "https://api.example.com/status")
+	assert.Contains(t, page.Content, "{{var.api_host}}")
+	assert.Contains(t, page.Content, "{{var.missing}}")
+	assert.Equal(t, []docweaver.Diagnostic{
+		{Severity: docweaver.SeverityWarning, Message: "Unknown variable `var.missing`."},
+	}, page.Diagnostics)
+}
+
+func TestProductRepository_GetPage_DwLinks(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "guides/deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, page.Content, fmt.Sprintf("<a href=\"/docs/%s/1.0/installation#links\">installation</a>", testProductKey))
+	assert.Contains(t, page.Content, fmt.Sprintf("<a href=\"/docs/%s/main/support\">product1:support</a>", testProductKey))
+	assert.Contains(t, page.Content, fmt.Sprintf("<a href=\"/docs/%s/main/guides/advanced/caching\">caching guide</a>", testProductKey))
+	assert.Contains(t, page.Content, "<a href=\"dw://unknown/latest/setup\">unknown:setup</a>")
+	assert.Equal(t, []docweaver.Diagnostic{
+		{Severity: docweaver.SeverityWarning, Message: "Unknown product `unknown` in link `dw://unknown/latest/setup`."},
+		{Severity: docweaver.SeverityWarning, Message: fmt.Sprintf("Unknown page `missing` of product `%s`, version `main` in link `dw://%s/main/missing`.", testProductKey, testProductKey)},
+	}, page.Diagnostics)
+}
+
+func TestProductRepository_GetPage_Sanitize(t *testing.T) {
+	t.Run("global", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeySanitize, "true")
+		page, err := repo.GetPage(testProductKey, "main", "guides/deploy")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Contains(t, page.Content, "<pre class=\"chroma\">")
+		assert.Contains(t, page.Content, "class=\"admonition admonition-warning\"")
+		assert.Contains(t, page.Content, "role=\"tablist\"")
+		assert.Contains(t, page.Index.Content, "<a href=\"http://iamreliq.com\" rel=\"noopener nofollow\">Website</a>")
+	})
+
+	t.Run("product meta", func(t *testing.T) {
+		docsDir := t.TempDir()
+		versionDir := filepath.Join(docsDir, "untrusted", "main")
+		if err := os.MkdirAll(versionDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]string{
+			".docweaver.yml":  "name: Untrusted\nsanitize: true\n",
+			"installation.md": "# Installation\n\n<script>alert(1)</script>\n\n<p onclick=\"alert(1)\">Text</p>\n",
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		page, err := docweaver.GetRepository(docsDir).GetPage("untrusted", "main", "installation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotContains(t, page.Content, "script")
+		assert.NotContains(t, page.Content, "onclick")
+		assert.Contains(t, page.Content, "<p>Text</p>")
+	})
+
+	t.Run("exemption", func(t *testing.T) {
+		docsDir := t.TempDir()
+		for _, key := range []string{"untrusted", "trusted"} {
+			versionDir := filepath.Join(docsDir, key, "main")
+			if err := os.MkdirAll(versionDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{
+				".docweaver.yml":  "name: Product\nsanitize: false\n",
+				"installation.md": "# Installation\n\n<script>alert(1)</script>\n",
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		t.Setenv(docweaver.EnvKeySanitize, "true")
+		t.Setenv(docweaver.EnvKeySanitizeExempt, "other, trusted")
+		r := docweaver.GetRepository(docsDir)
+
+		page, err := r.GetPage("untrusted", "main", "installation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotContains(t, page.Content, "script", "meta files may not disable sanitization")
+
+		page, err = r.GetPage("trusted", "main", "installation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, page.Content, "<script>alert(1)</script>")
+	})
+}
+
+func TestProductRepository_GetPage_Math(t *testing.T) {
+	page, err := repo.GetPage(testProductKey, "main", "guides/advanced/caching")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, page.Product.MathEnabled())
+	assert.Contains(t, page.Content, `<span class="math inline">\(h = \frac{hits}{hits + misses}\)</span>, costing $5 per month.`)
+	assert.Contains(t, page.Content, `<div class="math display">\[t_{avg} = h \cdot t_{cache} + (1 - h) \cdot t_{origin}\]</div>`)
+	assert.Contains(t, page.Content, "<code>$TTL</code>")
+}
+
+func TestProductRepository_GetPage_VersionFallback(t *testing.T) {
+	_, err := repo.GetPage(testProductKey, "0.9", "support")
+
+	var nfe *docweaver.PageNotFoundError
+	if !assert.ErrorAs(t, err, &nfe) {
+		return
+	}
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, []string{"1.0", "main"}, nfe.Versions)
+	assert.Empty(t, nfe.Fallback)
+
+	t.Run("redirect", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeyVersionFallback, docweaver.VersionFallbackRedirect)
+		_, err := repo.GetPage(testProductKey, "main", "guides/missing")
+		if assert.ErrorAs(t, err, &nfe) {
+			assert.Empty(t, nfe.Versions)
+			assert.Empty(t, nfe.Fallback)
+		}
+
+		_, err = repo.GetPage(testProductKey, "0.9", "support")
+		if assert.ErrorAs(t, err, &nfe) {
+			assert.Equal(t, "1.0", nfe.Fallback)
+		}
+	})
+
+	t.Run("latest version holding the page", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeyVersionFallback, docweaver.VersionFallbackRedirect)
+		docs, outside := t.TempDir(), t.TempDir()
+		files := map[string][]string{
+			"installation.md": {"0.9", "1.0", "2.0", "3.0", "main"},
+			"a.md":            {"1.0", "2.0"},
+			"b.md":            {"1.0"},
+			"c.md":            {"main"},
+		}
+		for name, versions := range files {
+			for _, version := range versions {
+				versionDir := filepath.Join(docs, "fallback", version)
+				if err := os.MkdirAll(versionDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(versionDir, name), []byte("# Page"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := os.WriteFile(filepath.Join(outside, "b.md"), []byte("# Outside"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(outside, "b.md"), filepath.Join(docs, "fallback", "2.0", "b.md")); err != nil {
+			t.Fatal(err)
+		}
+		r := docweaver.GetRepository(docs)
+
+		expected := map[string][]string{"a": {"2.0", "1.0"}, "b": {"1.0"}, "c": {"main"}}
+		for pagePath, versions := range expected {
+			_, err := r.GetPage("fallback", "0.9", pagePath)
+			if assert.ErrorAs(t, err, &nfe, pagePath) {
+				assert.ElementsMatch(t, versions, nfe.Versions, pagePath)
+				assert.Equal(t, versions[0], nfe.Fallback, pagePath)
+			}
+		}
+	})
+}
+
+func TestProductRepository_GetPage_Renderer(t *testing.T) {
+	renderer := docweaver.GetRenderer(docweaver.RendererOptions{Extensions: []goldmark.Extender{extension.DefinitionList}})
+	page, err := docweaver.GetRepositoryWithRenderer(docsDir, renderer).GetPage(testProductKey, "main", "support")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, page.Content, "<dl>")
+	assert.Contains(t, page.Index.Content, fmt.Sprintf("href=\"/docs/%s/main/installation\"", testProductKey))
+}
+
+func TestProductRepository_ListPages(t *testing.T) {
+	pages, err := repo.(docweaver.PageLister).ListPages(testProductKey, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"configuration", "guides", "guides/advanced/caching", "guides/deploy", "installation", "support",
+	}, pages)
+}
+
+func TestProductRepository_ListPages_Partials(t *testing.T) {
+	dir := t.TempDir()
+	if err := cp.Copy(filepath.Join(docsDir, testProductKey), filepath.Join(dir, testProductKey)); err != nil {
+		t.Fatal(err)
+	}
+	metaFile := filepath.Join(dir, testProductKey, "main", ".docweaver.yml")
+	listPages := func(meta string) []string {
+		if err := os.WriteFile(metaFile, []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pages, err := docweaver.GetRepository(dir).(docweaver.PageLister).ListPages(testProductKey, "main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pages
+	}
+
+	pages := listPages("partials: [guides/advanced]")
+	assert.Contains(t, pages, "partials/git")
+	assert.NotContains(t, pages, "guides/advanced/caching")
+	assert.Contains(t, pages, "guides/deploy")
+
+	pages = listPages("partials: []")
+	assert.Contains(t, pages, "partials/git")
+	assert.Contains(t, pages, "guides/advanced/caching")
+}
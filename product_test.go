@@ -0,0 +1,73 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// gitCommit commits all files in [dir] at the given unix time.
+func gitCommit(t *testing.T, dir string, ts string) {
+	t.Helper()
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "update", "--date", ts}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+ts, "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s %s", args, err, out)
+		}
+	}
+}
+
+func TestProductRoot_History(t *testing.T) {
+	r := productRoot{ParentDir: t.TempDir(), Key: "product"}
+	verPath := r.versionFilePath("1.0")
+	if err := os.MkdirAll(filepath.Join(verPath, "guides"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "init", "-q", verPath).CombinedOutput(); err != nil {
+		t.Fatalf("%s %s", err, out)
+	}
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(verPath, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("installation.md", "# Installation")
+	gitCommit(t, verPath, "@1600000000 +0000")
+	write("installation.md", "# Installation\n\nUpdated.")
+	write("guides/deploy.md", "# Deploy")
+	gitCommit(t, verPath, "@1700000000 +0000")
+
+	h, err := r.history("1.0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), h.commitTime)
+	assert.NotEmpty(t, h.commit)
+	assert.Equal(t, fileTimes{time.Unix(1600000000, 0).UTC(), time.Unix(1700000000, 0).UTC()}, h.files["installation.md"])
+	assert.Equal(t, fileTimes{time.Unix(1700000000, 0).UTC(), time.Unix(1700000000, 0).UTC()}, h.files["guides/deploy.md"])
+
+	created, modified := r.fileHistory("1.0", filepath.Join(verPath, "installation.md"))
+	assert.Equal(t, time.Unix(1600000000, 0).UTC(), created)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), modified)
+
+	cached, _ := r.history("1.0")
+	assert.Same(t, h, cached)
+
+	write("support.md", "# Support")
+	gitCommit(t, verPath, "@1800000000 +0000")
+	ct, err := r.commitTime("1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1800000000, 0).UTC(), ct)
+
+	_, err = r.history("main")
+	assert.Error(t, err)
+}
@@ -33,7 +33,8 @@ type UpdaterPublisher interface {
 }
 
 type publisher struct {
-	repo ProductRepository
+	repo     ProductRepository
+	searcher Searcher
 }
 
 var mainVersions = []string{versionMaster, versionMain}
@@ -41,16 +42,30 @@ var mainVersions = []string{versionMaster, versionMain}
 // GetPublisher returns the default instance of UpdaterPublisher.
 func GetPublisher() UpdaterPublisher {
 	return &publisher{
-		repo: &productRepository{getDocsDir()},
+		repo: &productRepository{dir: getDocsDir()},
 	}
 }
 
 // GetPublisherWithDocsDir returns an instance of UpdaterPublisher with the provided [dir].
 func GetPublisherWithDocsDir(docsDir string) UpdaterPublisher {
-	return &publisher{repo: &productRepository{docsDir}}
+	return &publisher{repo: &productRepository{dir: docsDir}}
+}
+
+// GetPublisherWithSearcher returns an instance of UpdaterPublisher with the provided [dir], which keeps the search
+// index of [searcher] up to date with published versions. The configured docs directory is used if [dir] is empty.
+func GetPublisherWithSearcher(docsDir string, searcher Searcher) UpdaterPublisher {
+	if docsDir == "" {
+		docsDir = getDocsDir()
+	}
+	return &publisher{repo: &productRepository{dir: docsDir}, searcher: searcher}
 }
 
 func (p *publisher) Publish(productKey string, source string, shouldUpdate bool) {
+	if err := validateProductKey(productKey); err != nil {
+		log(lError, "Failed to publish product. %s\n", err)
+		return
+	}
+
 	err := p.publish(productRoot{ParentDir: p.repo.GetDir(), Key: productKey, Source: source}, shouldUpdate)
 	if err == nil {
 		log(lInfo, "Successfully published product: `%s`.", productKey)
@@ -97,11 +112,21 @@ func (p *publisher) publish(pr productRoot, shouldUpdate bool) error {
 	}
 
 	for _, tag := range tags {
+		if err := validateVersion(tag); err != nil {
+			log(lWarn, "Skipped Tag `%s`. %s\n", tag, err)
+			continue
+		}
 		if err := p.publishProductVersion(pr, tag, shouldUpdate); err != nil {
 			log(lWarn, "Failed to publish/update Tag `%s`.", tag)
 		}
 	}
 
+	if p.searcher != nil {
+		if err := p.searcher.Save(); err != nil {
+			log(lError, "Failed to save search index after publishing product `%s`. %s\n", pr.Key, err)
+		}
+	}
+
 	return nil
 }
 
@@ -148,6 +173,12 @@ func (p *publisher) publishProductVersion(pr productRoot, version string, update
 		log(lError, "Failed to publish assets for version `%s`. %s\n", version, err)
 	}
 
+	if p.searcher != nil {
+		if err := p.searcher.IndexVersion(pr.Key, version); err != nil {
+			log(lError, "Failed to index version `%s` of product `%s`. %s\n", version, pr.Key, err)
+		}
+	}
+
 	return nil
 }
 
@@ -173,6 +204,11 @@ func (p *publisher) listProductTags(pr productRoot, baseVersion string) ([]strin
 
 func (p *publisher) Update(productKeys ...string) {
 	for _, productName := range productKeys {
+		if err := validateProductKey(productName); err != nil {
+			log(lError, "Failed to update product. %s\n", err)
+			continue
+		}
+
 		log(lInfo, "Updating product: `%s`\n", productName)
 		pr := productRoot{ParentDir: p.repo.GetDir(), Key: productName}
 		baseVersion := ""
@@ -245,14 +281,23 @@ func (p *publisher) getBVMErr(productName string) error {
 func (p *publisher) publishVersionAssets(pr productRoot, version string) error {
 	vPath := pr.versionFilePath(version)
 	assetsDir := GetAssetsDir()
-	imgDirName := "images"
 
 	if assetsDir == "" || assetsDir == getDocsDir() {
 		log(lInfo, "Assets directory is not configured or is same as docs dir. Skipping asset publication for `%s` version `%s`.\n", pr.Key, version)
 		return nil
 	}
 
+	if err := validateProductKey(pr.Key); err != nil {
+		return err
+	}
+	if err := validateVersion(version); err != nil {
+		return err
+	}
+
 	targetDir := fmt.Sprintf("%s%c%s%c%s", assetsDir, os.PathSeparator, pr.Key, os.PathSeparator, version)
+	if !isWithin(assetsDir, targetDir) {
+		return ValidationError{Field: FieldPath, Value: targetDir, Reason: fmt.Sprintf("Path resolves outside of `%s`.", assetsDir)}
+	}
 	log(lInfo, "Publishing assets for version `%s`. Target dir: `%s`\n", version, targetDir)
 
 	// publish images
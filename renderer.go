@@ -0,0 +1,68 @@
+package docweaver
+
+import (
+	"io"
+
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Renderer parses and renders the markdown of pages. It is shared by all pages and must be safe for concurrent use;
+// per-page state is passed to extensions via the parser context.
+type Renderer interface {
+	// Parse parses the markdown [source] of a page.
+	Parse(source []byte, pc parser.Context) ast.Node
+	// Render writes the HTML of a parsed page to [w].
+	Render(w io.Writer, source []byte, doc ast.Node) error
+}
+
+// RendererOptions configures the default Renderer. Extensions, options and transformers are added to those built in
+// (GFM, emoji, admonitions, tabs, link rewriting, syntax highlighting, math and diagrams).
+type RendererOptions struct {
+	Extensions    []goldmark.Extender
+	ParserOptions []parser.Option
+	HtmlOptions   []renderer.Option
+	// Transformers are AST transformers (parser.ASTTransformer) run after parsing, in order of priority.
+	Transformers []util.PrioritizedValue
+}
+
+type goldmarkRenderer struct {
+	markdown goldmark.Markdown
+}
+
+var defaultRenderer = GetRenderer(RendererOptions{})
+
+// GetRenderer returns a Renderer using goldmark, extended with the given options.
+func GetRenderer(options RendererOptions) Renderer {
+	extensions := append([]goldmark.Extender{
+		extension.GFM, emoji.Emoji, &admonitions{}, &tabs{}, &linkRewriter{}, &highlighter{}, &mathExtension{},
+		&diagrams{},
+	}, options.Extensions...)
+	parserOptions := append([]parser.Option{parser.WithAutoHeadingID()}, options.ParserOptions...)
+	if len(options.Transformers) > 0 {
+		parserOptions = append(parserOptions, parser.WithASTTransformers(options.Transformers...))
+	}
+
+	return &goldmarkRenderer{markdown: goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(append(
+			[]renderer.Option{html.WithHardWraps(), html.WithXHTML(), html.WithUnsafe()}, options.HtmlOptions...,
+		)...),
+	)}
+}
+
+func (r *goldmarkRenderer) Parse(source []byte, pc parser.Context) ast.Node {
+	return r.markdown.Parser().Parse(text.NewReader(source), parser.WithContext(pc))
+}
+
+func (r *goldmarkRenderer) Render(w io.Writer, source []byte, doc ast.Node) error {
+	return r.markdown.Renderer().Render(w, source, doc)
+}
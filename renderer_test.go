@@ -0,0 +1,78 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"testing"
+)
+
+type emphasisUpperTransformer struct{}
+
+func (t *emphasisUpperTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if e, ok := n.(*ast.Emphasis); ok && entering {
+			e.Level = 2
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func renderPage(t *testing.T, r Renderer, ctx *pageContext, source string) string {
+	pc := parser.NewContext()
+	if ctx != nil {
+		pc.Set(pageContextKey, ctx)
+	}
+	var out bytes.Buffer
+	if err := r.Render(&out, []byte(source), r.Parse([]byte(source), pc)); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestGetRenderer(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		out := renderPage(t, defaultRenderer, nil, "# Title\n\n> [!NOTE]\n> Text\n\n```go\nfunc main() {}\n```\n\nFootnote[^1].\n\n[^1]: Note.\n")
+
+		assert.Contains(t, out, "<h1 id=\"title\">Title</h1>")
+		assert.Contains(t, out, "<aside class=\"admonition admonition-note\">")
+		assert.Contains(t, out, "<pre><code class=\"language-go\">func main() {}\n</code></pre>")
+		assert.NotContains(t, out, "footnote")
+	})
+
+	t.Run("extensions and transformers", func(t *testing.T) {
+		r := GetRenderer(RendererOptions{
+			Extensions:   []goldmark.Extender{extension.Footnote},
+			Transformers: []util.PrioritizedValue{util.Prioritized(&emphasisUpperTransformer{}, 500)},
+		})
+		out := renderPage(t, r, nil, "Footnote[^1] and *emphasis*.\n\n[^1]: Note.\n")
+
+		assert.Contains(t, out, "class=\"footnote-ref\"")
+		assert.Contains(t, out, "<strong>emphasis</strong>")
+	})
+
+	t.Run("highlighting disabled by page", func(t *testing.T) {
+		disabled := false
+		ctx := newPageContext(nil, "foo", "1.0", "installation.md")
+		ctx.highlight = highlightConfig{Enabled: &disabled}
+		out := renderPage(t, defaultRenderer, ctx, "```go\nfunc main() {}\n```\n")
+
+		assert.Equal(t, "<pre><code class=\"language-go\">func main() {}\n</code></pre>\n", out)
+	})
+
+	t.Run("line numbers enabled by page", func(t *testing.T) {
+		ctx := newPageContext(nil, "foo", "1.0", "installation.md")
+		enabled := true
+		ctx.highlight = highlightConfig{Enabled: &enabled, LineNumbers: true}
+		out := renderPage(t, defaultRenderer, ctx, "```go\nfunc main() {}\n```\n")
+
+		assert.Contains(t, out, "<span class=\"ln\">1</span>")
+	})
+}
@@ -0,0 +1,203 @@
+package docweaver
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// sanitizer removes elements and attributes not allowed by the sanitization policy from rendered page content. The
+// policy allows the markup of markdown, GFM and docweaver's extensions (admonitions, tabs, highlighted code), drops
+// scripts, styles, embedded content and forms along with their content, and unwraps other unknown elements.
+type sanitizer struct {
+	siteHost string // host of the configured site URL; links to other hosts are external
+}
+
+const externalLinkRel = "noopener nofollow"
+
+var (
+	// sanitizeAllowedAttrs holds the attributes allowed per element, in addition to sanitizeGlobalAttrs.
+	sanitizeAllowedAttrs = map[atom.Atom][]string{
+		atom.A: {"href", "name", "rel", "target"}, atom.Abbr: nil, atom.Aside: nil, atom.B: nil,
+		atom.Blockquote: {"cite"}, atom.Br: nil, atom.Button: {"type"}, atom.Caption: nil, atom.Cite: nil,
+		atom.Code: nil, atom.Dd: nil, atom.Del: {"cite", "datetime"}, atom.Details: {"open"}, atom.Dfn: nil,
+		atom.Div: nil, atom.Dl: nil, atom.Dt: nil, atom.Em: nil, atom.Figcaption: nil, atom.Figure: nil,
+		atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil, atom.Hr: nil, atom.I: nil,
+		atom.Img: {"src", "alt", "width", "height", "loading"}, atom.Input: {"type", "checked", "disabled"},
+		atom.Ins: {"cite", "datetime"}, atom.Kbd: nil, atom.Li: {"value"}, atom.Mark: nil, atom.Ol: {"start", "reversed"},
+		atom.P: nil, atom.Pre: nil, atom.Q: {"cite"}, atom.S: nil, atom.Samp: nil, atom.Small: nil, atom.Span: nil,
+		atom.Strong: nil, atom.Sub: nil, atom.Summary: nil, atom.Sup: nil, atom.Table: nil, atom.Tbody: nil,
+		atom.Td: {"align", "colspan", "rowspan", "style"}, atom.Tfoot: nil,
+		atom.Th: {"align", "colspan", "rowspan", "scope", "style"}, atom.Thead: nil, atom.Time: {"datetime"},
+		atom.Tr: nil, atom.U: nil, atom.Ul: nil, atom.Var: nil,
+	}
+	// sanitizeGlobalAttrs holds the attributes allowed on all allowed elements.
+	sanitizeGlobalAttrs = []string{"id", "class", "title", "lang", "dir", "role", "hidden", "tabindex"}
+	// sanitizeDroppedElements are removed along with their content.
+	sanitizeDroppedElements = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+		atom.Frame: true, atom.Frameset: true, atom.Form: true, atom.Textarea: true, atom.Select: true,
+		atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true, atom.Title: true,
+		atom.Head: true, atom.Base: true, atom.Link: true, atom.Meta: true, atom.Applet: true,
+	}
+	sanitizeUrlAttrs        = map[string]bool{"href": true, "src": true, "cite": true}
+	sanitizeAllowedSchemes  = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+	sanitizeDataAttrPattern = regexp.MustCompile(`^(data|aria)-[a-z0-9-]+$`)
+	sanitizeStylePattern    = regexp.MustCompile(`^\s*text-align:\s*(left|center|right)\s*;?\s*$`)
+)
+
+// newSanitizer returns a sanitizer treating links to hosts other than that of the configured site URL as external.
+func newSanitizer() *sanitizer {
+	s := &sanitizer{}
+	if u, err := url.Parse(GetSiteUrl()); err == nil {
+		s.siteHost = u.Host
+	}
+	return s
+}
+
+// sanitize returns the sanitized HTML fragment. External links are given a `rel="noopener nofollow"` attribute.
+func (s *sanitizer) sanitize(content string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), context)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	s.sanitizeChildren(context)
+	for c := context.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			return "", err
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (s *sanitizer) sanitizeChildren(parent *html.Node) {
+	for c := parent.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			next = s.sanitizeElement(c, next)
+		default:
+			parent.RemoveChild(c)
+		}
+		c = next
+	}
+}
+
+// sanitizeElement sanitizes n and returns the node to continue with, which is n's first child if n was unwrapped.
+func (s *sanitizer) sanitizeElement(n, next *html.Node) *html.Node {
+	parent := n.Parent
+	allowed, ok := sanitizeAllowedAttrs[n.DataAtom]
+	switch {
+	case sanitizeDroppedElements[n.DataAtom], n.DataAtom == atom.Input && attrValue(n, "type") != "checkbox":
+		parent.RemoveChild(n)
+		return next
+	case !ok || n.Namespace != "":
+		first := n.FirstChild
+		for c := n.FirstChild; c != nil; c = n.FirstChild {
+			n.RemoveChild(c)
+			parent.InsertBefore(c, n)
+		}
+		parent.RemoveChild(n)
+		if first != nil {
+			return first
+		}
+		return next
+	}
+
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !s.allowedAttr(allowed, a) {
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+	if n.DataAtom == atom.A && s.isExternal(attrValue(n, "href")) {
+		setAttr(n, "rel", mergeRel(attrValue(n, "rel"), externalLinkRel))
+	}
+	s.sanitizeChildren(n)
+
+	return next
+}
+
+func (s *sanitizer) allowedAttr(allowed []string, a html.Attribute) bool {
+	key := strings.ToLower(a.Key)
+	switch {
+	case sanitizeUrlAttrs[key] && !allowedUrl(a.Val):
+		return false
+	case key == "style":
+		return sanitizeStylePattern.MatchString(a.Val) && containsString(allowed, key)
+	case sanitizeDataAttrPattern.MatchString(key):
+		return true
+	}
+
+	return containsString(sanitizeGlobalAttrs, key) || containsString(allowed, key)
+}
+
+// isExternal reports whether href is an absolute link to another site.
+func (s *sanitizer) isExternal(href string) bool {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return !strings.EqualFold(u.Host, s.siteHost)
+}
+
+// allowedUrl reports whether a URL is relative or uses an allowed scheme.
+func allowedUrl(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || sanitizeAllowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+// mergeRel adds the space separated values of add to rel, omitting those already present.
+func mergeRel(rel, add string) string {
+	values := strings.Fields(rel)
+	for _, v := range strings.Fields(add) {
+		if !containsString(values, v) {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSanitizer_Sanitize(t *testing.T) {
+	s := &sanitizer{siteHost: "docs.example.com"}
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"script", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"event handler", `<img src="/a.png" alt="a" onerror="alert(1)"/>`, `<img src="/a.png" alt="a"/>`},
+		{"javascript url", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"unknown element", `<p><font color="red">red <b>bold</b></font>!</p>`, `<p>red <b>bold</b>!</p>`},
+		{"comment", `<p>a<!-- hidden --></p>`, `<p>a</p>`},
+		{"iframe", `<iframe src="https://evil.example.com"></iframe><p>a</p>`, `<p>a</p>`},
+		{"external link", `<a href="https://example.com/x">x</a>`, `<a href="https://example.com/x" rel="noopener nofollow">x</a>`},
+		{"external link rel", `<a href="https://example.com" rel="me noopener" target="_blank">x</a>`, `<a href="https://example.com" rel="me noopener nofollow" target="_blank">x</a>`},
+		{"internal links", `<a href="/docs/foo/1.0/support#help">a</a><a href="https://docs.example.com/docs">b</a><a href="#top">c</a>`,
+			`<a href="/docs/foo/1.0/support#help">a</a><a href="https://docs.example.com/docs">b</a><a href="#top">c</a>`},
+		{"style", `<p style="color:red">a</p><table><tbody><tr><td style="text-align: center">b</td><td style="background:url(x)">c</td></tr></tbody></table>`,
+			`<p>a</p><table><tbody><tr><td style="text-align: center">b</td><td>c</td></tr></tbody></table>`},
+		{"task list", `<li><input checked="" disabled="" type="checkbox"/> done</li><input type="text" value="x"/>`,
+			`<li><input checked="" disabled="" type="checkbox"/> done</li>`},
+		{
+			"admonition",
+			`<details class="admonition admonition-tip" open=""><summary class="admonition-title">Tip</summary><p>a</p></details>`,
+			`<details class="admonition admonition-tip" open=""><summary class="admonition-title">Tip</summary><p>a</p></details>`,
+		},
+		{
+			"tabs",
+			`<div class="tabs" data-tabs-key="pm"><div class="tabs-list" role="tablist"><button type="button" class="tabs-tab" role="tab" id="tabs-1-tab-1" aria-controls="tabs-1-panel-1" aria-selected="true" tabindex="0" data-tab="npm">npm</button></div><div class="tabs-panel" role="tabpanel" id="tabs-1-panel-1" hidden="">x</div></div>`,
+			`<div class="tabs" data-tabs-key="pm"><div class="tabs-list" role="tablist"><button type="button" class="tabs-tab" role="tab" id="tabs-1-tab-1" aria-controls="tabs-1-panel-1" aria-selected="true" tabindex="0" data-tab="npm">npm</button></div><div class="tabs-panel" role="tabpanel" id="tabs-1-panel-1" hidden="">x</div></div>`,
+		},
+		{
+			"highlighted code",
+			`<pre class="chroma"><code class="language-go" data-lang="go"><span class="line hl"><span class="kd">func</span></span></code></pre>`,
+			`<pre class="chroma"><code class="language-go" data-lang="go"><span class="line hl"><span class="kd">func</span></span></code></pre>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sanitized, err := s.sanitize(tt.content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, sanitized)
+		})
+	}
+}
+
+func TestMergeRel(t *testing.T) {
+	assert.Equal(t, "noopener nofollow", mergeRel("", externalLinkRel))
+	assert.Equal(t, "external nofollow noopener", mergeRel("external nofollow", externalLinkRel))
+}
@@ -0,0 +1,489 @@
+package docweaver
+
+import (
+	"encoding/gob"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/reliqarts/go-common"
+	xhtml "golang.org/x/net/html"
+)
+
+// Searcher indexes the pages of all products and versions for full-text search.
+type Searcher interface {
+	// IndexAll (re)indexes every version of every product, removing versions which no longer exist from the index.
+	IndexAll() error
+	// IndexVersion (re)indexes all pages of a product version.
+	IndexVersion(productKey, version string) error
+	// RemoveVersion removes all pages of a product version from the index.
+	RemoveVersion(productKey, version string)
+	// Search returns pages matching the query, best match first.
+	Search(query SearchQuery) []SearchResult
+	// Save persists the index to its file.
+	Save() error
+}
+
+// SearchQuery describes a search. Results are limited to the given product and version if set.
+type SearchQuery struct {
+	Text       string
+	ProductKey string
+	Version    string
+	Limit      int // maximum number of results, defaults to 20
+}
+
+// SearchResult is a page matching a search query.
+type SearchResult struct {
+	ProductKey string
+	Version    string
+	PagePath   string
+	Title      string  // page title
+	Heading    string  // heading of the best matching section
+	Url        string  // page URL, anchored to the best matching section
+	Snippet    string  // HTML excerpt of the best matching section with matches wrapped in <mark>
+	Score      float64 // BM25 score of the best matching section
+}
+
+type searcher struct {
+	repo      ProductRepository
+	indexFile string
+	mu        sync.RWMutex
+	index     *searchIndex
+}
+
+// searchIndex is an inverted index of page sections. Sections (a heading and the text up to the next heading) are
+// the indexed documents; term frequencies are weighted by field.
+type searchIndex struct {
+	Docs        map[int]*searchDoc
+	Postings    map[string]map[int]float64 // term -> doc ID -> weighted term frequency
+	NextID      int
+	TotalLength float64
+}
+
+type searchDoc struct {
+	ProductKey string
+	Version    string
+	PagePath   string
+	PageTitle  string
+	Heading    string
+	Anchor     string
+	Text       string
+	Length     float64
+}
+
+// field weights and BM25 parameters
+const (
+	searchTitleWeight   float64 = 3
+	searchHeadingWeight float64 = 2
+	searchTextWeight    float64 = 1
+	bm25K1              float64 = 1.2
+	bm25B               float64 = 0.75
+
+	defaultSearchLimit   = 20
+	searchSnippetLength  = 200
+	searchSnippetContext = 60
+)
+
+// GetSearcher returns a Searcher for the pages of [repo], persisted to [indexFile]. The configured search index file
+// is used if [indexFile] is empty. An existing index is loaded from the file.
+func GetSearcher(repo ProductRepository, indexFile string) (Searcher, error) {
+	if repo == nil {
+		repo = GetRepository("")
+	}
+	if indexFile == "" {
+		indexFile = GetSearchIndexFile()
+	}
+
+	s := &searcher{repo: repo, indexFile: indexFile, index: newSearchIndex()}
+	f, err := os.Open(indexFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(s.index); err != nil {
+		return nil, simpleError{fmt.Sprintf("Failed to load search index from `%s`. %s", indexFile, err)}
+	}
+
+	return s, nil
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{Docs: map[int]*searchDoc{}, Postings: map[string]map[int]float64{}}
+}
+
+func (s *searcher) IndexAll() error {
+	products, err := s.repo.FindAllProducts()
+	if err != nil {
+		return err
+	}
+
+	present := map[[2]string]bool{}
+	for _, p := range products {
+		for _, v := range p.Versions {
+			if err := s.IndexVersion(p.Key(), v); err != nil {
+				return err
+			}
+			present[[2]string{p.Key(), v}] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.index.Docs {
+		if key := [2]string{d.ProductKey, d.Version}; !present[key] {
+			log(lInfo, "Removing product `%s`, version `%s` from search index.\n", d.ProductKey, d.Version)
+			s.index.remove(d.ProductKey, d.Version)
+			present[key] = true
+		}
+	}
+
+	return nil
+}
+
+func (s *searcher) IndexVersion(productKey, version string) error {
+	pagePaths, err := listPages(s.repo, productKey, version)
+	if err != nil {
+		return err
+	}
+
+	var docs []*searchDoc
+	for _, pagePath := range pagePaths {
+		page, err := s.repo.GetPage(productKey, version, pagePath)
+		if err != nil {
+			log(lWarn, "Failed to index page `%s` of product `%s`, version `%s`. %s\n", pagePath, productKey, version, err)
+			continue
+		}
+		if page.Draft {
+			continue
+		}
+		docs = append(docs, pageSections(page)...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.remove(productKey, version)
+	for _, d := range docs {
+		s.index.add(d)
+	}
+	log(lInfo, "Indexed %d sections of product `%s`, version `%s`.\n", len(docs), productKey, version)
+
+	return nil
+}
+
+func (s *searcher) RemoveVersion(productKey, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.remove(productKey, version)
+}
+
+func (s *searcher) Search(query SearchQuery) []SearchResult {
+	terms := common.RemoveDuplicates(tokenize(query.Text))
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := s.index.score(terms, func(d *searchDoc) bool {
+		return (query.ProductKey == "" || d.ProductKey == query.ProductKey) &&
+			(query.Version == "" || d.Version == query.Version)
+	})
+
+	// keep the best matching section per page
+	best := map[string]int{}
+	for id, score := range scores {
+		d := s.index.Docs[id]
+		key := fmt.Sprintf("%s/%s/%s", d.ProductKey, d.Version, d.PagePath)
+		if cur, ok := best[key]; !ok || score > scores[cur] || (score == scores[cur] && id < cur) {
+			best[key] = id
+		}
+	}
+
+	var results []SearchResult
+	for _, id := range best {
+		d := s.index.Docs[id]
+		url := fmt.Sprintf("%s/%s/%s/%s", normalizeRoutePrefix(GetRoutePrefix()), d.ProductKey, d.Version, d.PagePath)
+		if d.Anchor != "" {
+			url = fmt.Sprintf("%s#%s", url, d.Anchor)
+		}
+		results = append(results, SearchResult{
+			ProductKey: d.ProductKey,
+			Version:    d.Version,
+			PagePath:   d.PagePath,
+			Title:      d.PageTitle,
+			Heading:    d.Heading,
+			Url:        url,
+			Snippet:    snippet(d.Text, terms),
+			Score:      scores[id],
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Url < results[j].Url
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func (s *searcher) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.indexFile), 0755); err != nil {
+		return err
+	}
+
+	tmpFile := s.indexFile + tempNameSuffix
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(s.index); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, s.indexFile)
+}
+
+func (idx *searchIndex) add(d *searchDoc) {
+	id := idx.NextID
+	idx.NextID++
+
+	tf := map[string]float64{}
+	for _, t := range tokenize(d.PageTitle) {
+		tf[t] += searchTitleWeight
+	}
+	for _, t := range tokenize(d.Heading) {
+		tf[t] += searchHeadingWeight
+	}
+	for _, t := range tokenize(d.Text) {
+		tf[t] += searchTextWeight
+	}
+
+	for t, f := range tf {
+		if idx.Postings[t] == nil {
+			idx.Postings[t] = map[int]float64{}
+		}
+		idx.Postings[t][id] = f
+		d.Length += f
+	}
+	idx.Docs[id] = d
+	idx.TotalLength += d.Length
+}
+
+func (idx *searchIndex) remove(productKey, version string) {
+	removed := map[int]bool{}
+	for id, d := range idx.Docs {
+		if d.ProductKey == productKey && d.Version == version {
+			removed[id] = true
+			idx.TotalLength -= d.Length
+			delete(idx.Docs, id)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	for t, postings := range idx.Postings {
+		for id := range postings {
+			if removed[id] {
+				delete(postings, id)
+			}
+		}
+		if len(postings) == 0 {
+			delete(idx.Postings, t)
+		}
+	}
+}
+
+// score computes BM25 scores of all documents accepted by filter containing any of the given terms.
+func (idx *searchIndex) score(terms []string, filter func(d *searchDoc) bool) map[int]float64 {
+	scores := map[int]float64{}
+	n := float64(len(idx.Docs))
+	if n == 0 {
+		return scores
+	}
+	avgLength := idx.TotalLength / n
+
+	for _, t := range terms {
+		postings := idx.Postings[t]
+		df := float64(len(postings))
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, tf := range postings {
+			d := idx.Docs[id]
+			if !filter(d) {
+				continue
+			}
+			scores[id] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*d.Length/avgLength))
+		}
+	}
+
+	return scores
+}
+
+// pageSections splits the rendered content of a page into indexable sections, one per heading.
+func pageSections(page *Page) []*searchDoc {
+	current := &searchDoc{ProductKey: page.Product.Key(), Version: page.Version, PagePath: page.UrlPath, PageTitle: page.Title}
+	sections := []*searchDoc{current}
+	var text strings.Builder
+	inHeading, skip := false, 0
+
+	flush := func() {
+		current.Text = strings.Join(strings.Fields(text.String()), " ")
+		text.Reset()
+	}
+
+	z := xhtml.NewTokenizer(strings.NewReader(page.Content))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+
+		tok := z.Token()
+		switch tt {
+		case xhtml.StartTagToken:
+			switch tok.Data {
+			case "script", "style":
+				skip++
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				flush()
+				current = &searchDoc{ProductKey: current.ProductKey, Version: current.Version, PagePath: current.PagePath, PageTitle: current.PageTitle}
+				for _, a := range tok.Attr {
+					if a.Key == "id" {
+						current.Anchor = a.Val
+					}
+				}
+				sections = append(sections, current)
+				inHeading = true
+			}
+		case xhtml.EndTagToken:
+			switch tok.Data {
+			case "script", "style":
+				if skip > 0 {
+					skip--
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				inHeading = false
+			default:
+				text.WriteByte(' ')
+			}
+		case xhtml.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if inHeading {
+				current.Heading += tok.Data
+			} else {
+				text.WriteString(tok.Data)
+			}
+		}
+	}
+	flush()
+
+	var docs []*searchDoc
+	for _, d := range sections {
+		d.Heading = strings.TrimSpace(d.Heading)
+		if d.Heading != "" || d.Text != "" {
+			docs = append(docs, d)
+		}
+	}
+
+	return docs
+}
+
+// tokenize splits text into lower case terms of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// snippet returns an HTML excerpt of text around the first occurrence of any of the given terms, with all
+// occurrences wrapped in <mark>.
+func snippet(text string, terms []string) string {
+	matches := map[string]bool{}
+	for _, t := range terms {
+		matches[t] = true
+	}
+
+	type word struct{ start, end int }
+	var words []word
+	start := -1
+	for i, r := range text + " " {
+		isWordRune := unicode.IsLetter(r) || unicode.IsNumber(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			if matches[strings.ToLower(text[start:i])] {
+				words = append(words, word{start, i})
+			}
+			start = -1
+		}
+	}
+
+	from := 0
+	if len(words) > 0 && words[0].start > searchSnippetContext {
+		from = words[0].start - searchSnippetContext
+	}
+	to := from + searchSnippetLength
+	if to > len(text) {
+		to = len(text)
+	}
+	// align the window to rune boundaries
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, w := range words {
+		if w.start < from || w.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:w.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[w.start:w.end]))
+		b.WriteString("</mark>")
+		pos = w.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,97 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	cp "github.com/otiai10/copy"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearcher_Search(t *testing.T) {
+	indexFile := filepath.Join(t.TempDir(), "index.gob")
+	searcher, err := docweaver.GetSearcher(repo, indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := searcher.IndexAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	results := searcher.Search(docweaver.SearchQuery{Text: "caching"})
+	if !assert.NotEmpty(t, results) {
+		return
+	}
+	assert.Equal(t, "guides/advanced/caching", results[0].PagePath)
+	assert.Contains(t, results[0].Snippet, "<mark>caching</mark>")
+
+	results = searcher.Search(docweaver.SearchQuery{Text: "environment", ProductKey: testProductKey, Version: "main"})
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, "Environment", results[0].Heading)
+	assert.Equal(t, fmt.Sprintf("/docs/%s/main/configuration#environment", testProductKey), results[0].Url)
+
+	for _, r := range searcher.Search(docweaver.SearchQuery{Text: "support", Version: "1.0"}) {
+		assert.Equal(t, "1.0", r.Version)
+	}
+	assert.Empty(t, searcher.Search(docweaver.SearchQuery{Text: "support", ProductKey: "unknown"}))
+
+	t.Run("persistence", func(t *testing.T) {
+		if err := searcher.Save(); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := docweaver.GetSearcher(repo, indexFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, searcher.Search(docweaver.SearchQuery{Text: "caching"}), loaded.Search(docweaver.SearchQuery{Text: "caching"}))
+	})
+
+	t.Run("remove version", func(t *testing.T) {
+		searcher.RemoveVersion(testProductKey, "main")
+
+		assert.Empty(t, searcher.Search(docweaver.SearchQuery{Text: "caching"}))
+		assert.NotEmpty(t, searcher.Search(docweaver.SearchQuery{Text: "support", Version: "1.0"}))
+	})
+}
+
+func TestSearcher_IndexAll_RemovesDeletedVersions(t *testing.T) {
+	dir := t.TempDir()
+	if err := cp.Copy(filepath.Join(docsDir, testProductKey), filepath.Join(dir, testProductKey)); err != nil {
+		t.Fatal(err)
+	}
+	searcher, err := docweaver.GetSearcher(docweaver.GetRepository(dir), filepath.Join(dir, "index.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := searcher.IndexAll(); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, searcher.Search(docweaver.SearchQuery{Text: "support", Version: "1.0"}))
+
+	if err := os.RemoveAll(filepath.Join(dir, testProductKey, "1.0")); err != nil {
+		t.Fatal(err)
+	}
+	if err := searcher.IndexAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, searcher.Search(docweaver.SearchQuery{Text: "support", Version: "1.0"}))
+	assert.NotEmpty(t, searcher.Search(docweaver.SearchQuery{Text: "support", Version: "main"}))
+}
+
+func TestSearcher_IndexVersion_RequiresPageLister(t *testing.T) {
+	plain := struct{ docweaver.ProductRepository }{repo}
+	searcher, err := docweaver.GetSearcher(plain, filepath.Join(t.TempDir(), "index.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, searcher.IndexVersion(testProductKey, "main"))
+}
@@ -0,0 +1,43 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"go", "1", "17", "caching", "héllo"}, tokenize("Go 1.17: *Caching*, héllo!"))
+}
+
+func TestSnippet(t *testing.T) {
+	assert.Equal(t, "Use <mark>caching</mark> &amp; <mark>Caching</mark>.", snippet("Use caching & Caching.", []string{"caching"}))
+	assert.Equal(t, "no match", snippet("no match", []string{"caching"}))
+
+	long := "lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et " +
+		"dolore magna aliqua ut enim ad minim veniam quis nostrud exercitation ullamco laboris nisi ut aliquip " +
+		"ex ea commodo consequat duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore " +
+		"eu fugiat nulla pariatur excepteur sint occaecat cupidatat non proident sunt in culpa qui officia"
+	s := snippet(long, []string{"laboris"})
+	assert.Contains(t, s, "<mark>laboris</mark>")
+	assert.Regexp(t, "^…", s)
+	assert.Regexp(t, "…$", s)
+}
+
+func TestSearchIndex(t *testing.T) {
+	idx := newSearchIndex()
+	idx.add(&searchDoc{ProductKey: "a", Version: "1.0", PageTitle: "Caching", Text: "How to configure things."})
+	idx.add(&searchDoc{ProductKey: "a", Version: "1.0", PageTitle: "Deploy", Text: "Caching is mentioned once."})
+	idx.add(&searchDoc{ProductKey: "b", Version: "2.0", PageTitle: "Other", Text: "Nothing relevant."})
+	all := func(d *searchDoc) bool { return true }
+
+	scores := idx.score([]string{"caching"}, all)
+	assert.Len(t, scores, 2)
+	assert.Greater(t, scores[0], scores[1], "title matches should outweigh body matches")
+
+	idx.remove("a", "1.0")
+	assert.Len(t, idx.Docs, 1)
+	assert.Empty(t, idx.score([]string{"caching"}, all))
+	assert.NotContains(t, idx.Postings, "caching")
+}
@@ -0,0 +1,177 @@
+package docweaver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc      string `xml:"loc"`
+	LastMod  string `xml:"lastmod,omitempty"`
+	Priority string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapUrl `xml:"sitemap"`
+}
+
+const (
+	sitemapFileName = "sitemap.xml"
+	robotsFileName  = "robots.txt"
+	sitemapXmlns    = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+	// priorities of product listing and pages by version
+	sitemapPriorityListing = "1.0"
+	sitemapPriorityLatest  = "0.8"
+	sitemapPriorityMain    = "0.5"
+	sitemapPriorityOther   = "0.3"
+)
+
+// GenerateSitemap generates sitemap.xml and robots.txt for all pages of all products of [repo], keyed by file name.
+// Sitemaps exceeding the configured URL limit are split into numbered sitemaps (sitemap-1.xml, ...) referenced by a
+// sitemap index in sitemap.xml. Locations are prefixed with the configured site URL.
+func GenerateSitemap(repo ProductRepository) (map[string][]byte, error) {
+	siteUrl := strings.TrimRight(GetSiteUrl(), "/")
+	if siteUrl == "" {
+		log(lWarn, "Site URL is not configured. Sitemap locations will be relative.\n")
+	}
+
+	urls, lastMod, err := sitemapUrls(repo, siteUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	maxUrls := GetSitemapMaxUrls()
+	if maxUrls <= 0 || len(urls) <= maxUrls {
+		b, err := marshalXml(sitemapUrlSet{Xmlns: sitemapXmlns, Urls: urls})
+		if err != nil {
+			return nil, err
+		}
+		files[sitemapFileName] = b
+	} else {
+		index := sitemapIndex{Xmlns: sitemapXmlns}
+		for i := 0; i*maxUrls < len(urls); i++ {
+			end := (i + 1) * maxUrls
+			if end > len(urls) {
+				end = len(urls)
+			}
+
+			name := fmt.Sprintf("sitemap-%d.xml", i+1)
+			b, err := marshalXml(sitemapUrlSet{Xmlns: sitemapXmlns, Urls: urls[i*maxUrls : end]})
+			if err != nil {
+				return nil, err
+			}
+			files[name] = b
+			index.Sitemaps = append(index.Sitemaps, sitemapUrl{Loc: fmt.Sprintf("%s/%s", siteUrl, name), LastMod: lastMod})
+		}
+
+		b, err := marshalXml(index)
+		if err != nil {
+			return nil, err
+		}
+		files[sitemapFileName] = b
+	}
+
+	files[robotsFileName] = robotsTxt()
+
+	return files, nil
+}
+
+// robotsTxt returns the robots.txt allowing all crawlers and referring to the sitemap.
+func robotsTxt() []byte {
+	return []byte(fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s/%s\n", strings.TrimRight(GetSiteUrl(), "/"), sitemapFileName))
+}
+
+// sitemapConfig returns the configuration sitemaps depend on, for use as part of cache keys.
+func sitemapConfig() string {
+	return fmt.Sprintf("%s|%s|%d|%t", GetSiteUrl(), GetRoutePrefix(), GetSitemapMaxUrls(), GetSitemapLatestOnly())
+}
+
+// sitemapUrls lists the product listing and all (non-draft) pages. The latest modification time of all pages is
+// returned along with the URLs.
+func sitemapUrls(repo ProductRepository, siteUrl string) (urls []sitemapUrl, lastMod string, err error) {
+	products, err := repo.FindAllProducts()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var latest time.Time
+	listing := sitemapUrl{Loc: siteUrl + routeRoot(), Priority: sitemapPriorityListing}
+	urls = append(urls, listing)
+
+	for _, p := range products {
+		for _, version := range p.Versions {
+			priority := sitemapPriorityOther
+			switch {
+			case version == p.LatestVersion:
+				priority = sitemapPriorityLatest
+			case isMainVersion(version):
+				priority = sitemapPriorityMain
+			case GetSitemapLatestOnly():
+				continue
+			}
+
+			pagePaths, err := listPages(repo, p.Key(), version)
+			if err != nil {
+				return nil, "", err
+			}
+			for _, pagePath := range pagePaths {
+				filePath := p.root.pageFilePath(version, pagePath)
+				md, err := os.ReadFile(filePath)
+				if err != nil {
+					return nil, "", err
+				}
+				if fm, _, _, _ := parseFrontMatter(md); fm.Draft {
+					continue
+				}
+
+				u := sitemapUrl{Loc: fmt.Sprintf("%s%s/%s/%s", siteUrl, p.BaseUrl, version, pagePath), Priority: priority}
+				if _, modified := p.root.fileHistory(version, filePath); !modified.IsZero() {
+					u.LastMod = modified.Format(time.RFC3339)
+					if modified.After(latest) {
+						latest = modified
+					}
+				}
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	if !latest.IsZero() {
+		lastMod = latest.Format(time.RFC3339)
+		urls[0].LastMod = lastMod
+	}
+
+	return urls, lastMod, nil
+}
+
+// isSitemapFileName reports whether name may be one of the files generated by GenerateSitemap.
+func isSitemapFileName(name string) bool {
+	return name == robotsFileName || (strings.HasPrefix(name, "sitemap") && strings.HasSuffix(name, ".xml") && !strings.Contains(name, "/"))
+}
+
+func marshalXml(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
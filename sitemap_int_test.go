@@ -0,0 +1,72 @@
+//go:build integration || ci
+
+package docweaver_test
+
+import (
+	"fmt"
+	"github.com/reliqarts/go-docweaver"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGenerateSitemap(t *testing.T) {
+	t.Setenv(docweaver.EnvKeySiteUrl, "https://example.com/")
+	files, err := docweaver.GenerateSitemap(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitemap := string(files["sitemap.xml"])
+	assert.Contains(t, sitemap, "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">")
+	assert.Contains(t, sitemap, "<loc>https://example.com/docs</loc>")
+	assert.Contains(t, sitemap, fmt.Sprintf("<loc>https://example.com/docs/%s/1.0/installation</loc>\n    <lastmod>", testProductKey))
+	assert.Regexp(t, "1.0/support</loc>\\s*<lastmod>[^<]+</lastmod>\\s*<priority>0.8</priority>", sitemap)
+	assert.Regexp(t, "main/guides/deploy</loc>\\s*<lastmod>[^<]+</lastmod>\\s*<priority>0.5</priority>", sitemap)
+	assert.Regexp(t, "0.9/installation</loc>\\s*<lastmod>[^<]+</lastmod>\\s*<priority>0.3</priority>", sitemap)
+	assert.NotContains(t, sitemap, "2.0-temp")
+	assert.Equal(t, "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n", string(files["robots.txt"]))
+
+	t.Run("split", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeySitemapMaxUrls, "5")
+		files, err := docweaver.GenerateSitemap(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Contains(t, string(files["sitemap.xml"]), "<sitemapindex")
+		assert.Contains(t, string(files["sitemap.xml"]), "<loc>https://example.com/sitemap-2.xml</loc>")
+		assert.Contains(t, string(files["sitemap-1.xml"]), "<urlset")
+		assert.Len(t, files, 4, "index, two sitemaps and robots.txt expected")
+	})
+
+	t.Run("latest only", func(t *testing.T) {
+		t.Setenv(docweaver.EnvKeySitemapLatestOnly, "true")
+		t.Setenv(docweaver.EnvKeySitemapMaxUrls, "")
+		files, err := docweaver.GenerateSitemap(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Contains(t, string(files["sitemap.xml"]), "/1.0/installation")
+		assert.Contains(t, string(files["sitemap.xml"]), "/main/installation")
+		assert.NotContains(t, string(files["sitemap.xml"]), "/0.9/")
+	})
+}
+
+func TestHttpHandler_ServeHTTP_Sitemap(t *testing.T) {
+	for path, contentType := range map[string]string{"/sitemap.xml": "application/xml", "/robots.txt": "text/plain"} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Get("Content-Type"), contentType)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap-9.xml", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,44 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSitemapUrls_LastMod(t *testing.T) {
+	docsDir := t.TempDir()
+	verPath := filepath.Join(docsDir, "product", "1.0")
+	if err := os.MkdirAll(verPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "init", "-q", verPath).CombinedOutput(); err != nil {
+		t.Fatalf("%s %s", err, out)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(verPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("installation.md", "# Installation")
+	gitCommit(t, verPath, "@1600000000 +0000")
+	write("support.md", "# Support")
+	gitCommit(t, verPath, "@1700000000 +0000")
+
+	urls, lastMod, err := sitemapUrls(GetRepository(docsDir), "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lastMods := map[string]string{}
+	for _, u := range urls {
+		lastMods[u.Loc] = u.LastMod
+	}
+	assert.Equal(t, "2020-09-13T12:26:40Z", lastMods["/docs/product/1.0/installation"])
+	assert.Equal(t, "2023-11-14T22:13:20Z", lastMods["/docs/product/1.0/support"])
+	assert.Equal(t, "2023-11-14T22:13:20Z", lastMod)
+}
@@ -0,0 +1,317 @@
+package docweaver
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Tabs is a group of tabs, written either as a `tabs` container holding `tab` containers or as consecutive fenced
+// code blocks with a `tab` attribute. Groups sharing a Key are kept in sync by TabsScript.
+type Tabs struct {
+	ast.BaseBlock
+	Key string
+	id  int
+}
+
+// Tab is a single tab of a Tabs group.
+type Tab struct {
+	ast.BaseBlock
+	Label string
+	Value string // label based value of the tab's data-tab attribute
+	index int
+}
+
+var (
+	// KindTabs is the ast.NodeKind of Tabs nodes.
+	KindTabs = ast.NewNodeKind("Tabs")
+	// KindTab is the ast.NodeKind of Tab nodes.
+	KindTab = ast.NewNodeKind("Tab")
+)
+
+// tabs renders tab groups.
+type tabs struct{}
+
+type tabsParser struct{}
+
+type tabsTransformer struct{}
+
+const (
+	tabsContainerName = "tabs"
+	tabContainerName  = "tab"
+	// tabAttr and tabGroupAttr are the fence info attributes grouping consecutive code blocks into tabs.
+	tabAttr      = "tab"
+	tabGroupAttr = "group"
+)
+
+var tabValueReplacePattern = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// TabsScript switches tabs on click and arrow keys, syncs tab groups sharing a key and remembers the chosen tab per
+// key. It is included by the built-in page template and available to custom templates via the `tabsScript` function.
+const TabsScript = `<script>
+(function () {
+  function select(group, value) {
+    var found = false;
+    group.querySelectorAll(':scope > .tabs-list > [role="tab"]').forEach(function (tab) {
+      var selected = tab.dataset.tab === value;
+      found = found || selected;
+      tab.setAttribute('aria-selected', selected);
+      tab.tabIndex = selected ? 0 : -1;
+      document.getElementById(tab.getAttribute('aria-controls')).hidden = !selected;
+    });
+    if (found) group.dataset.tabsSelected = value;
+    return found;
+  }
+  function choose(group, value) {
+    var key = group.dataset.tabsKey;
+    if (!key) return select(group, value);
+    document.querySelectorAll('.tabs[data-tabs-key="' + CSS.escape(key) + '"]').forEach(function (g) { select(g, value); });
+    try { localStorage.setItem('docweaver-tabs:' + key, value); } catch (e) {}
+  }
+  document.addEventListener('click', function (e) {
+    var tab = e.target.closest('.tabs-list > [role="tab"]');
+    if (tab) choose(tab.closest('.tabs'), tab.dataset.tab);
+  });
+  document.addEventListener('keydown', function (e) {
+    var tab = e.target.closest('.tabs-list > [role="tab"]');
+    if (!tab || (e.key !== 'ArrowLeft' && e.key !== 'ArrowRight')) return;
+    var tabs = Array.prototype.slice.call(tab.parentNode.children);
+    var next = tabs[(tabs.indexOf(tab) + (e.key === 'ArrowRight' ? 1 : tabs.length - 1)) % tabs.length];
+    choose(next.closest('.tabs'), next.dataset.tab);
+    next.focus();
+  });
+  document.querySelectorAll('.tabs[data-tabs-key]').forEach(function (group) {
+    try {
+      var value = localStorage.getItem('docweaver-tabs:' + group.dataset.tabsKey);
+      if (value) select(group, value);
+    } catch (e) {}
+  });
+})();
+</script>`
+
+func (n *Tabs) Kind() ast.NodeKind {
+	return KindTabs
+}
+
+func (n *Tabs) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Key": n.Key}, nil)
+}
+
+func (n *Tab) Kind() ast.NodeKind {
+	return KindTab
+}
+
+func (n *Tab) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Label": n.Label}, nil)
+}
+
+func newTab(label string) *Tab {
+	label = strings.TrimSpace(label)
+	return &Tab{Label: label, Value: tabValue(label)}
+}
+
+// tabValue returns the value identifying a tab with the given label, e.g. "macos" for "macOS".
+func tabValue(label string) string {
+	return strings.Trim(tabValueReplacePattern.ReplaceAllString(strings.ToLower(label), "-"), "-")
+}
+
+// tabsScript returns TabsScript for use in templates.
+func tabsScript() template.HTML {
+	return TabsScript
+}
+
+func (t *tabs) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&tabsParser{}, 140)),
+		parser.WithASTTransformers(util.Prioritized(&tabsTransformer{}, 110)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(t, 100)))
+}
+
+func (t *tabs) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindTabs, t.renderTabs)
+	reg.Register(KindTab, t.renderTab)
+}
+
+func (t *tabs) renderTabs(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*Tabs)
+	var group []*Tab
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if tab, ok := c.(*Tab); ok {
+			group = append(group, tab)
+		}
+	}
+
+	_, _ = w.WriteString("<div class=\"tabs\"")
+	if n.Key != "" {
+		_, _ = fmt.Fprintf(w, " data-tabs-key=\"%s\"", html.EscapeString(n.Key))
+	}
+	if len(group) > 0 {
+		_, _ = fmt.Fprintf(w, " data-tabs-selected=\"%s\"", html.EscapeString(group[0].Value))
+	}
+	_, _ = w.WriteString(">\n<div class=\"tabs-list\" role=\"tablist\">\n")
+	for _, tab := range group {
+		selected, tabIndex := "false", "-1"
+		if tab.index == 0 {
+			selected, tabIndex = "true", "0"
+		}
+		_, _ = fmt.Fprintf(w,
+			"<button type=\"button\" class=\"tabs-tab\" role=\"tab\" id=\"%s\" aria-controls=\"%s\" aria-selected=\"%s\" tabindex=\"%s\" data-tab=\"%s\">%s</button>\n",
+			tabId(n.id, tab.index), tabPanelId(n.id, tab.index), selected, tabIndex, html.EscapeString(tab.Value), html.EscapeString(tab.Label),
+		)
+	}
+	_, _ = w.WriteString("</div>\n")
+
+	return ast.WalkContinue, nil
+}
+
+func (t *tabs) renderTab(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*Tab)
+	groupId := 0
+	if group, ok := n.Parent().(*Tabs); ok {
+		groupId = group.id
+	}
+	hidden := ""
+	if n.index > 0 {
+		hidden = " hidden"
+	}
+	_, _ = fmt.Fprintf(w, "<div class=\"tabs-panel\" role=\"tabpanel\" id=\"%s\" aria-labelledby=\"%s\" tabindex=\"0\" data-tab=\"%s\"%s>\n",
+		tabPanelId(groupId, n.index), tabId(groupId, n.index), html.EscapeString(n.Value), hidden)
+
+	return ast.WalkContinue, nil
+}
+
+func tabId(groupId, index int) string {
+	return fmt.Sprintf("tabs-%d-tab-%d", groupId, index+1)
+}
+
+func tabPanelId(groupId, index int) string {
+	return fmt.Sprintf("tabs-%d-panel-%d", groupId, index+1)
+}
+
+func (p *tabsParser) Trigger() []byte {
+	return []byte{containerFenceChar}
+}
+
+func (p *tabsParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	fence, ok := parseContainerFence(line, reader.LineOffset())
+	if !ok {
+		return nil, parser.NoChildren
+	}
+
+	var node ast.Node
+	switch _, isGroup := parent.(*Tabs); {
+	case fence.name == tabsContainerName:
+		key := parseFenceInfo(fence.rest).Attrs["key"]
+		if key == "" {
+			key = fence.rest
+		}
+		node = &Tabs{Key: key}
+	case fence.name == tabContainerName && isGroup:
+		node = newTab(fence.rest)
+	default:
+		return nil, parser.NoChildren
+	}
+
+	openContainer(pc, node, fence.length)
+	advanceLine(reader, line, segment)
+
+	return node, parser.HasChildren
+}
+
+func (p *tabsParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return continueContainer(node, reader, pc)
+}
+
+func (p *tabsParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	closeContainer(node, pc)
+}
+
+func (p *tabsParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *tabsParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// Transform groups consecutive fenced code blocks having a `tab` attribute into tabs and numbers all tab groups of the
+// document. The first `group` attribute of the blocks is used as the group's key.
+func (t *tabsTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	tabAttrs := func(n ast.Node) (label, group string, ok bool) {
+		block, isBlock := n.(*ast.FencedCodeBlock)
+		if !isBlock || block.Info == nil {
+			return "", "", false
+		}
+		info := parseFenceInfo(string(block.Info.Segment.Value(source)))
+		label, ok = info.Attrs[tabAttr]
+		return label, info.Attrs[tabGroupAttr], ok
+	}
+
+	var starts []ast.Node
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, _, ok := tabAttrs(n); ok && entering {
+			if _, _, prevOk := tabAttrs(n.PreviousSibling()); !prevOk {
+				starts = append(starts, n)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, start := range starts {
+		parent := start.Parent()
+		group := &Tabs{}
+		parent.InsertBefore(parent, start, group)
+		for n := start; n != nil; {
+			label, key, ok := tabAttrs(n)
+			if !ok {
+				break
+			}
+			if group.Key == "" {
+				group.Key = key
+			}
+			next := n.NextSibling()
+			tab := newTab(label)
+			tab.AppendChild(tab, n)
+			group.AppendChild(group, tab)
+			n = next
+		}
+	}
+
+	id := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if group, ok := n.(*Tabs); ok && entering {
+			id++
+			group.id = id
+			index := 0
+			for c := group.FirstChild(); c != nil; c = c.NextSibling() {
+				if tab, ok := c.(*Tab); ok {
+					tab.index = index
+					index++
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
@@ -0,0 +1,70 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"testing"
+)
+
+func TestTabs(t *testing.T) {
+	render := func(source string) string {
+		var out bytes.Buffer
+		if err := goldmark.New(goldmark.WithExtensions(&admonitions{}, &tabs{})).Convert([]byte(source), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	t.Run("fenced code blocks", func(t *testing.T) {
+		out := render("```sh tab=npm group=pkg\nnpm i\n```\n```sh tab=\"Yarn Classic\"\nyarn add\n```\n\n```sh\nplain\n```\n")
+
+		assert.Equal(t, `<div class="tabs" data-tabs-key="pkg" data-tabs-selected="npm">
+<div class="tabs-list" role="tablist">
+<button type="button" class="tabs-tab" role="tab" id="tabs-1-tab-1" aria-controls="tabs-1-panel-1" aria-selected="true" tabindex="0" data-tab="npm">npm</button>
+<button type="button" class="tabs-tab" role="tab" id="tabs-1-tab-2" aria-controls="tabs-1-panel-2" aria-selected="false" tabindex="-1" data-tab="yarn-classic">Yarn Classic</button>
+</div>
+<div class="tabs-panel" role="tabpanel" id="tabs-1-panel-1" aria-labelledby="tabs-1-tab-1" tabindex="0" data-tab="npm">
+<pre><code class="language-sh">npm i
+</code></pre>
+</div>
+<div class="tabs-panel" role="tabpanel" id="tabs-1-panel-2" aria-labelledby="tabs-1-tab-2" tabindex="0" data-tab="yarn-classic" hidden>
+<pre><code class="language-sh">yarn add
+</code></pre>
+</div>
+</div>
+<pre><code class="language-sh">plain
+</code></pre>
+`, out)
+	})
+
+	t.Run("containers", func(t *testing.T) {
+		out := render("::::tabs os\n:::tab Linux\nUse *apt*.\n:::\n:::tab macOS\n:::note\nUse brew.\n:::\n:::\n::::\n\n```sh tab=Other\nx\n```\n")
+
+		assert.Contains(t, out, "<div class=\"tabs\" data-tabs-key=\"os\" data-tabs-selected=\"linux\">")
+		assert.Contains(t, out, "data-tab=\"macos\">macOS</button>")
+		assert.Contains(t, out, "<div class=\"tabs-panel\" role=\"tabpanel\" id=\"tabs-1-panel-1\" aria-labelledby=\"tabs-1-tab-1\" tabindex=\"0\" data-tab=\"linux\">\n<p>Use <em>apt</em>.</p>\n</div>")
+		assert.Contains(t, out, "data-tab=\"macos\" hidden>\n<aside class=\"admonition admonition-note\">")
+		assert.Contains(t, out, "id=\"tabs-2-tab-1\"")
+	})
+
+	t.Run("closing fence at end of file", func(t *testing.T) {
+		out := render("::::tabs\n:::tab Linux\nUse apt.\n:::\n::::")
+
+		assert.Contains(t, out, "data-tab=\"linux\">\n<p>Use apt.</p>\n</div>")
+		assert.NotContains(t, out, "<p>:")
+		assert.NotContains(t, render("::::tabs\n:::tab Linux"), "<p>")
+	})
+
+	t.Run("tab outside of tabs", func(t *testing.T) {
+		assert.Equal(t, "<p>:::tab Lonely\nx\n:::</p>\n", render(":::tab Lonely\nx\n:::\n"))
+	})
+}
+
+func TestTabValue(t *testing.T) {
+	assert.Equal(t, "macos", tabValue("macOS"))
+	assert.Equal(t, "yarn-classic", tabValue(" Yarn  Classic! "))
+	assert.Equal(t, "c", tabValue("C++"))
+}
@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+func main() {
+	// region: deploy
+	fmt.Println("Deploying...")
+	// endregion
+}
@@ -0,0 +1,54 @@
+package docweaver
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// TocEntry is a heading within a page's table of contents.
+type TocEntry struct {
+	Level    int
+	Text     string
+	ID       string // anchor ID of the heading
+	Children []*TocEntry
+}
+
+// buildToc builds a hierarchical table of contents from the headings of a parsed document with a level between
+// minLevel and maxLevel (inclusive). Headings nest beneath the closest preceding heading of a lower level.
+func buildToc(doc ast.Node, source []byte, minLevel, maxLevel int) []*TocEntry {
+	var toc []*TocEntry
+	var stack []*TocEntry
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+
+		entry := &TocEntry{Level: heading.Level, Text: strings.TrimSpace(nodeText(heading, source))}
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				entry.ID = string(b)
+			}
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			toc = append(toc, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return toc
+}
@@ -0,0 +1,40 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"testing"
+)
+
+func TestBuildToc(t *testing.T) {
+	source := []byte(`# Title
+## Setup
+### Linux
+#### Details
+### Windows
+## Setup
+### Linux
+## *Usage* notes
+`)
+	doc := goldmark.New(goldmark.WithParserOptions(parser.WithAutoHeadingID())).Parser().Parse(text.NewReader(source))
+
+	toc := buildToc(doc, source, 2, 3)
+	assert.Equal(t, []*TocEntry{
+		{Level: 2, Text: "Setup", ID: "setup", Children: []*TocEntry{
+			{Level: 3, Text: "Linux", ID: "linux"},
+			{Level: 3, Text: "Windows", ID: "windows"},
+		}},
+		{Level: 2, Text: "Setup", ID: "setup-1", Children: []*TocEntry{
+			{Level: 3, Text: "Linux", ID: "linux-1"},
+		}},
+		{Level: 2, Text: "Usage notes", ID: "usage-notes"},
+	}, toc)
+
+	deep := buildToc(doc, source, 3, 6)
+	assert.Len(t, deep, 3)
+	assert.Equal(t, "details", deep[0].Children[0].ID)
+}
@@ -1,9 +1,12 @@
 package docweaver
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/reliqarts/go-common"
 	"golang.org/x/net/html"
+	"io"
 	goLog "log"
 	"net/url"
 	"os"
@@ -38,6 +41,20 @@ const (
 	EnvKeyAssetsRoutePrefix string = "DW_ASSETS_ROUTE_PREFIX" // Assets route prefix environment key.
 	EnvKeySourcesFile       string = "DW_SOURCES_FILE"        // Sources file environment key.
 	EnvKeyShowLogs          string = "DW_SHOW_LOGS"           // Show logs environment key.
+	EnvKeyPageCacheControl  string = "DW_PAGE_CACHE_CONTROL"  // Page Cache-Control header environment key.
+	EnvKeyAssetCacheControl string = "DW_ASSET_CACHE_CONTROL" // Asset Cache-Control header environment key.
+	EnvKeyTocMinLevel       string = "DW_TOC_MIN_LEVEL"       // Minimum table of contents heading level environment key.
+	EnvKeyTocMaxLevel       string = "DW_TOC_MAX_LEVEL"       // Maximum table of contents heading level environment key.
+	EnvKeySearchIndexFile   string = "DW_SEARCH_INDEX_FILE"   // Search index file environment key.
+	EnvKeySiteUrl           string = "DW_SITE_URL"            // Absolute site URL environment key.
+	EnvKeySitemapMaxUrls    string = "DW_SITEMAP_MAX_URLS"    // Maximum URLs per sitemap environment key.
+	EnvKeySitemapLatestOnly string = "DW_SITEMAP_LATEST_ONLY" // Sitemap old version exclusion environment key.
+	EnvKeySanitize          string = "DW_SANITIZE"            // Page content sanitization environment key.
+	EnvKeySanitizeExempt    string = "DW_SANITIZE_EXEMPT"     // Trusted products exempt from sanitization environment key.
+	EnvKeyVersionFallback   string = "DW_VERSION_FALLBACK"    // Missing page version fallback environment key.
+	EnvKeyMathAssetsUrl     string = "DW_MATH_ASSETS_URL"     // KaTeX assets base URL environment key.
+	EnvKeyMermaidUrl        string = "DW_MERMAID_URL"         // Mermaid ES module URL environment key.
+	EnvKeyVizUrl            string = "DW_VIZ_URL"             // Viz.js script URL environment key.
 
 	defaultDocumentationDir  string = "./tmp/docs"
 	defaultVersion                  = versionMain
@@ -45,11 +62,24 @@ const (
 	defaultAssetsRoutePrefix        = "/doc-assets"
 	defaultSourcesFile              = "./doc-sources.yml"
 	defaultShowLogs                 = "true"
+	defaultPageCacheControl         = "public, max-age=0, must-revalidate"
+	defaultAssetCacheControl        = "public, max-age=86400"
+	defaultTocMinLevel              = 2
+	defaultTocMaxLevel              = 3
+	defaultSearchIndexFile          = "./tmp/search-index.gob"
+	defaultSitemapMaxUrls           = 50000
+	defaultSitemapLatestOnly        = false
+	defaultSanitize                 = false
+	defaultVersionFallback          = VersionFallbackSuggest
+	defaultMathAssetsUrl            = "https://cdn.jsdelivr.net/npm/katex@" + katexVersion + "/dist"
+	defaultMermaidUrl               = "https://cdn.jsdelivr.net/npm/mermaid@" + mermaidVersion + "/dist/mermaid.esm.min.mjs"
+	defaultVizUrl                   = "https://cdn.jsdelivr.net/npm/@viz-js/viz@" + vizVersion + "/lib/viz-standalone.js"
 
 	metaFileName string = ".docweaver.yml"
 
 	versionMaster       string = "master"
 	versionMain         string = "main"
+	versionNone         string = "N/A"
 	versionPlaceholder  string = "{{version}}"
 	assetUrlPlaceholder string = "{{docs}}"
 
@@ -75,6 +105,13 @@ func getLoggerSet() *loggerSet {
 	}
 }
 
+// SetLogOutput sets the destination of the package's logs, which is stdout by default.
+func SetLogOutput(w io.Writer) {
+	loggers.Err.SetOutput(w)
+	loggers.Info.SetOutput(w)
+	loggers.Warn.SetOutput(w)
+}
+
 // GetAssetsDir returns configured assets directory. env key: DW_ASSETS_DIR
 func GetAssetsDir() string {
 	return common.GetEnvOrDefault(EnvKeyAssetsDir, getDocsDir())
@@ -95,6 +132,111 @@ func GetSourcesFilePath() string {
 	return common.GetEnvOrDefault(EnvKeySourcesFile, defaultSourcesFile)
 }
 
+// GetPageCacheControl returns configured Cache-Control header value for pages. env key: DW_PAGE_CACHE_CONTROL
+func GetPageCacheControl() string {
+	return common.GetEnvOrDefault(EnvKeyPageCacheControl, defaultPageCacheControl)
+}
+
+// GetAssetCacheControl returns configured Cache-Control header value for assets. env key: DW_ASSET_CACHE_CONTROL
+func GetAssetCacheControl() string {
+	return common.GetEnvOrDefault(EnvKeyAssetCacheControl, defaultAssetCacheControl)
+}
+
+// GetTocMinLevel returns configured minimum heading level of page tables of contents. env key: DW_TOC_MIN_LEVEL
+func GetTocMinLevel() int {
+	return getEnvIntOrDefault(EnvKeyTocMinLevel, defaultTocMinLevel)
+}
+
+// GetTocMaxLevel returns configured maximum heading level of page tables of contents. env key: DW_TOC_MAX_LEVEL
+func GetTocMaxLevel() int {
+	return getEnvIntOrDefault(EnvKeyTocMaxLevel, defaultTocMaxLevel)
+}
+
+// GetSearchIndexFile returns configured search index file path. env key: DW_SEARCH_INDEX_FILE
+func GetSearchIndexFile() string {
+	return common.GetEnvOrDefault(EnvKeySearchIndexFile, defaultSearchIndexFile)
+}
+
+// GetSiteUrl returns configured absolute site URL, e.g. https://example.com. env key: DW_SITE_URL
+func GetSiteUrl() string {
+	return common.GetEnvOrDefault(EnvKeySiteUrl, "")
+}
+
+// GetSitemapMaxUrls returns configured maximum number of URLs per sitemap. env key: DW_SITEMAP_MAX_URLS
+func GetSitemapMaxUrls() int {
+	return getEnvIntOrDefault(EnvKeySitemapMaxUrls, defaultSitemapMaxUrls)
+}
+
+// GetSitemapLatestOnly returns whether versions other than the latest and main versions are excluded from
+// sitemaps. env key: DW_SITEMAP_LATEST_ONLY
+func GetSitemapLatestOnly() bool {
+	return getEnvBoolOrDefault(EnvKeySitemapLatestOnly, defaultSitemapLatestOnly)
+}
+
+// GetSanitize returns whether the content of pages is sanitized. Products may enable sanitization in their meta file,
+// but are only exempt from it if listed in GetSanitizeExempt. env key: DW_SANITIZE
+func GetSanitize() bool {
+	return getEnvBoolOrDefault(EnvKeySanitize, defaultSanitize)
+}
+
+// GetSanitizeExempt returns the keys of trusted products whose content is not sanitized even if GetSanitize is
+// enabled, configured as a comma separated list. env key: DW_SANITIZE_EXEMPT
+func GetSanitizeExempt() []string {
+	var keys []string
+	for _, key := range strings.Split(common.GetEnvOrDefault(EnvKeySanitizeExempt, ""), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetVersionFallback returns how pages missing in a requested version are handled, unless configured otherwise by a
+// product's meta file: "suggest" (default) or "redirect". env key: DW_VERSION_FALLBACK
+func GetVersionFallback() string {
+	fallback := common.GetEnvOrDefault(EnvKeyVersionFallback, defaultVersionFallback)
+	if fallback != VersionFallbackSuggest && fallback != VersionFallbackRedirect {
+		log(lWarn, "Invalid version fallback configured for `%s`. Using default (%s).\n", EnvKeyVersionFallback, defaultVersionFallback)
+		return defaultVersionFallback
+	}
+	return fallback
+}
+
+// GetMathAssetsUrl returns the configured base URL KaTeX (katex.min.css, katex.min.js and fonts) is loaded from, which
+// must serve the files of the pinned KaTeX version. env key: DW_MATH_ASSETS_URL
+func GetMathAssetsUrl() string {
+	return strings.TrimRight(common.GetEnvOrDefault(EnvKeyMathAssetsUrl, defaultMathAssetsUrl), "/")
+}
+
+// GetMermaidUrl returns the configured URL of the mermaid ES module loaded by pages holding mermaid diagrams.
+// env key: DW_MERMAID_URL
+func GetMermaidUrl() string {
+	return common.GetEnvOrDefault(EnvKeyMermaidUrl, defaultMermaidUrl)
+}
+
+// GetVizUrl returns the configured URL of the Viz.js script loaded by pages holding graphviz diagrams.
+// env key: DW_VIZ_URL
+func GetVizUrl() string {
+	return common.GetEnvOrDefault(EnvKeyVizUrl, defaultVizUrl)
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	v, err := strconv.ParseBool(common.GetEnvOrDefault(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	v, err := strconv.Atoi(common.GetEnvOrDefault(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log(lWarn, "Invalid integer value configured for `%s`. Using default (%d).\n", key, defaultValue)
+		return defaultValue
+	}
+	return v
+}
+
 func getDocsDir() string {
 	return common.GetEnvOrDefault(EnvKeyDocsDir, defaultDocumentationDir)
 }
@@ -122,7 +264,7 @@ func getPageTitleFromHtml(content string) string {
 }
 
 func replaceLinks(productKey, version, content string) string {
-	routePrefix := GetRoutePrefix()
+	routePrefix := normalizeRoutePrefix(GetRoutePrefix())
 	linkReplacement := fmt.Sprintf("%s/%s/%s", routePrefix, productKey, version)
 	repl := strings.NewReplacer(
 		assetUrlPlaceholder, linkReplacement,
@@ -132,9 +274,29 @@ func replaceLinks(productKey, version, content string) string {
 		versionPlaceholder, version,
 		url.QueryEscape(versionPlaceholder), version,
 	)
+	content = repl.Replace(content)
+	if routePrefix == "" {
+		return content
+	}
 	slashPrefixRepl := strings.NewReplacer(fmt.Sprintf("=\"/%s", routePrefix), fmt.Sprintf("=\"%s", routePrefix))
 
-	return slashPrefixRepl.Replace(repl.Replace(content))
+	return slashPrefixRepl.Replace(content)
+}
+
+// replaceLinkDestination replaces placeholders within a single link destination.
+func replaceLinkDestination(productKey, version, destination string) string {
+	link := replaceLinks(productKey, version, destination)
+	if routePrefix := normalizeRoutePrefix(GetRoutePrefix()); routePrefix != "" && strings.HasPrefix(link, "/"+routePrefix) {
+		return strings.TrimPrefix(link, "/")
+	}
+
+	return link
+}
+
+// contentHash returns the hex encoded SHA-256 hash of the given content.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
 }
 
 // sortVersions sorts a given slice of versions
@@ -168,13 +330,13 @@ func latestVersion(versions []string) (latest string) {
 
 	// focus on non-main versions
 	for _, v := range versions {
-		if v != versionMaster && v != versionMain {
+		if !isMainVersion(v) {
 			vs = append(vs, v)
 		}
 	}
 	if len(vs) == 0 {
 		// no non-main versions exist, i.e. no version
-		return "N/A"
+		return versionNone
 	}
 
 	sortVersions(vs)
@@ -199,6 +361,10 @@ func latestVersion(versions []string) (latest string) {
 	return
 }
 
+func isMainVersion(version string) bool {
+	return version == versionMaster || version == versionMain
+}
+
 func removeDir(dir string) (error error) {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		error = err
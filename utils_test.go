@@ -3,8 +3,10 @@
 package docweaver
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"testing"
 )
 
@@ -20,6 +22,21 @@ func TestGetLoggerSet(t *testing.T) {
 	assert.Contains(t, loggerSet.Info.Prefix(), "info")
 	assert.Contains(t, loggerSet.Err.Prefix(), "err")
 	assert.Contains(t, loggerSet.Warn.Prefix(), "warn")
+	assert.Equal(t, os.Stdout, loggerSet.Warn.Writer())
+}
+
+func TestSetLogOutput(t *testing.T) {
+	var out bytes.Buffer
+	SetLogOutput(&out)
+	defer SetLogOutput(os.Stdout)
+	t.Setenv(EnvKeyShowLogs, "true")
+
+	log(lWarn, "Careful.\n")
+	log(lInfo, "Done.\n")
+
+	assert.Contains(t, out.String(), "[Dw][warn] ")
+	assert.Contains(t, out.String(), "Careful.\n")
+	assert.Contains(t, out.String(), "[Dw][info] ")
 }
 
 func TestReplaceLinks(t *testing.T) {
@@ -39,6 +56,11 @@ func TestReplaceLinks(t *testing.T) {
 			content:  "docs/{{version}}/something/somewhere",
 			expected: fmt.Sprintf("%s/%s/2.0/something/somewhere", GetRoutePrefix(), productKey),
 		},
+		{
+			version:  "2.0",
+			content:  "<a href=\"/docs/{{version}}/guides/advanced/caching\">",
+			expected: fmt.Sprintf("<a href=\"%s/%s/2.0/guides/advanced/caching\">", GetRoutePrefix(), productKey),
+		},
 		{
 			version:  "v5.0",
 			content:  "{{version}}/some-thing/somewhere",
@@ -56,6 +78,29 @@ func TestReplaceLinks(t *testing.T) {
 	}
 }
 
+func TestReplaceLinks_RelativeRoutePrefix(t *testing.T) {
+	t.Setenv(EnvKeyRoutePrefix, "docs")
+
+	assert.Equal(
+		t,
+		"<a href=\"/docs/prod-up/2.0/guides/deploy\">",
+		replaceLinks("prod-up", "2.0", "<a href=\"{{docs}}/guides/deploy\">"),
+	)
+}
+
+func TestReplaceLinks_RootRoutePrefix(t *testing.T) {
+	t.Setenv(EnvKeyRoutePrefix, "/")
+
+	assert.Equal(t, "", normalizeRoutePrefix(GetRoutePrefix()))
+	assert.Equal(t, "/", routeRoot())
+	assert.Equal(
+		t,
+		"<a href=\"/prod-up/2.0/guides/deploy\"><a href=\"/about\">",
+		replaceLinks("prod-up", "2.0", "<a href=\"{{docs}}/guides/deploy\"><a href=\"/about\">"),
+	)
+	assert.Equal(t, "/about", replaceLinkDestination("prod-up", "2.0", "/about"))
+}
+
 func TestSortVersions(t *testing.T) {
 	testData := []testStringSet{
 		{
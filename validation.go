@@ -0,0 +1,106 @@
+package docweaver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationError is returned when a product key, version or page path is rejected.
+type ValidationError struct {
+	Field  string // name of the rejected input, e.g. "product key"
+	Value  string // rejected value
+	Reason string
+}
+
+// names of validated inputs
+const (
+	FieldProductKey string = "product key"
+	FieldVersion    string = "version"
+	FieldPagePath   string = "page path"
+	FieldPath       string = "path"
+)
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("Invalid %s `%s`. %s", e.Field, e.Value, e.Reason)
+}
+
+// validateSegment ensures value may be used as a single path segment (directory or file name) beneath a root.
+func validateSegment(field, value string) error {
+	reason := ""
+	switch {
+	case value == "":
+		reason = "Value must not be empty."
+	case value == "." || value == "..":
+		reason = "Relative path segments are not allowed."
+	case strings.HasPrefix(value, "."):
+		reason = "Hidden names are not allowed."
+	case strings.ContainsAny(value, "/\\"):
+		reason = "Path separators are not allowed."
+	case strings.ContainsRune(value, 0):
+		reason = "Null bytes are not allowed."
+	case filepath.IsAbs(value) || filepath.VolumeName(value) != "":
+		reason = "Absolute paths are not allowed."
+	}
+
+	if reason != "" {
+		return ValidationError{Field: field, Value: value, Reason: reason}
+	}
+	return nil
+}
+
+// validateProductKey ensures a product key may be used to address a product directory.
+func validateProductKey(productKey string) error {
+	return validateSegment(FieldProductKey, productKey)
+}
+
+// validateVersion ensures a version may be used to address a version directory.
+func validateVersion(version string) error {
+	return validateSegment(FieldVersion, version)
+}
+
+// validatePagePath ensures a page path is relative and each of its (slash separated) segments is valid.
+func validatePagePath(pagePath string) error {
+	if strings.HasPrefix(pagePath, "/") || filepath.IsAbs(pagePath) {
+		return ValidationError{Field: FieldPagePath, Value: pagePath, Reason: "Absolute paths are not allowed."}
+	}
+
+	for _, segment := range strings.Split(pagePath, "/") {
+		if err := validateSegment(FieldPagePath, segment); err != nil {
+			e := err.(ValidationError)
+			e.Value = pagePath
+			return e
+		}
+	}
+
+	return nil
+}
+
+// confinePath resolves symbolic links in path and ensures the result lies within root (itself resolved).
+// The resolved path is returned.
+func confinePath(root, path string) (string, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !isWithin(resolvedRoot, resolved) {
+		return "", ValidationError{Field: FieldPath, Value: path, Reason: fmt.Sprintf("Path resolves outside of `%s`.", root)}
+	}
+
+	return resolved, nil
+}
+
+// isWithin reports whether path is lexically equal to or beneath root.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
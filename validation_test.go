@@ -0,0 +1,105 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSegment(t *testing.T) {
+	testData := []struct {
+		value string
+		valid bool
+	}{
+		{"product1", true},
+		{"v1.0", true},
+		{"2.0-beta", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{".git", false},
+		{"foo/bar", false},
+		{"foo\\bar", false},
+		{"/etc", false},
+		{"foo\x00", false},
+	}
+
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			err := validateSegment(FieldVersion, td.value)
+			if td.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			var ve ValidationError
+			assert.True(t, errors.As(err, &ve))
+			assert.Equal(t, FieldVersion, ve.Field)
+		})
+	}
+}
+
+func TestValidatePagePath(t *testing.T) {
+	testData := []struct {
+		value string
+		valid bool
+	}{
+		{"installation", true},
+		{"guides/deploy", true},
+		{"../../etc/passwd", false},
+		{"guides/../../secret", false},
+		{"/etc/passwd", false},
+		{"guides//deploy", false},
+		{"guides/.hidden", false},
+	}
+
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			err := validatePagePath(td.value)
+			if td.valid {
+				assert.NoError(t, err)
+				return
+			}
+
+			var ve ValidationError
+			assert.True(t, errors.As(err, &ve))
+			assert.Equal(t, td.value, ve.Value)
+		})
+	}
+}
+
+func TestConfinePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	inside := filepath.Join(root, "page.md")
+	escaping := filepath.Join(root, "escape.md")
+	if err := os.WriteFile(inside, []byte("# Page"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.md"), []byte("# Secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.md"), escaping); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := confinePath(root, inside)
+	assert.NoError(t, err)
+
+	_, err = confinePath(root, escaping)
+	var ve ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, FieldPath, ve.Field)
+}
+
+func TestIsWithin(t *testing.T) {
+	assert.True(t, isWithin("/docs", "/docs/product/1.0"))
+	assert.True(t, isWithin("/docs", "/docs"))
+	assert.False(t, isWithin("/docs", "/docs/../etc"))
+	assert.False(t, isWithin("/docs", "/docs-other/product"))
+	assert.False(t, isWithin("/docs", "/"))
+}
@@ -0,0 +1,112 @@
+package docweaver
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// variableLookup returns the value of the variable with the given name and whether it is defined.
+type variableLookup func(name string) (string, bool)
+
+const (
+	variablePrefix = "var."
+	// noVariablesAttr is the fence info attribute by which code blocks opt out of variable substitution (`vars=false`).
+	noVariablesAttr = "vars"
+)
+
+var variablePattern = regexp.MustCompile(`\{\{\s*((?:var\.)?[\w-]+)\s*\}\}`)
+
+// substituteVariables replaces variables (`{{var.name}}` and built-ins such as `{{product}}`) within [md]. Fenced code
+// blocks with a `vars=false` attribute are left as they are. Undefined `var.` variables are left in place and reported
+// as warnings; other unknown names (e.g. `{{version}}`, replaced after rendering) are ignored.
+func substituteVariables(md []byte, lookup variableLookup) ([]byte, []Diagnostic) {
+	var out bytes.Buffer
+	var diagnostics []Diagnostic
+	reported := map[string]bool{}
+	fence, skip := "", false
+
+	for rest := md; len(rest) > 0; {
+		var line []byte
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i+1], rest[i+1:]
+		} else {
+			line, rest = rest, nil
+		}
+
+		opened := fence == ""
+		fence = codeFence(string(line), fence)
+		if fence != "" && opened {
+			info := strings.TrimLeft(strings.TrimSpace(string(line)), fence[:1])
+			skip = parseFenceInfo(info).Attrs[noVariablesAttr] == "false"
+			out.Write(line)
+			continue
+		}
+		if skip {
+			if fence == "" {
+				skip = false
+			}
+			out.Write(line)
+			continue
+		}
+
+		out.Write(variablePattern.ReplaceAllFunc(line, func(match []byte) []byte {
+			name := string(variablePattern.FindSubmatch(match)[1])
+			if value, ok := lookup(name); ok {
+				return []byte(value)
+			}
+			if strings.HasPrefix(name, variablePrefix) && !reported[name] {
+				reported[name] = true
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("Unknown variable `%s`.", name),
+				})
+			}
+			return match
+		}))
+	}
+
+	return out.Bytes(), diagnostics
+}
+
+// pageVariables returns the lookup of variables of pages of the given product version. Declared variables of the
+// version's meta file take precedence over those of the product's meta file.
+func pageVariables(p *Product, version string) variableLookup {
+	declared := map[string]string{}
+	for k, v := range p.meta.Variables {
+		declared[k] = v
+	}
+	if meta, err := p.readMeta(version); err == nil && meta != nil {
+		for k, v := range meta.Variables {
+			declared[k] = v
+		}
+	}
+
+	var commit *string
+
+	return func(name string) (string, bool) {
+		if strings.HasPrefix(name, variablePrefix) {
+			value, ok := declared[strings.TrimPrefix(name, variablePrefix)]
+			return value, ok
+		}
+
+		switch name {
+		case "product":
+			return p.Name, true
+		case "latest_version":
+			return p.currentVersion(), true
+		case "commit":
+			if commit == nil {
+				c, err := p.root.commit(version)
+				if err != nil {
+					log(lInfo, "No commit for version `%s` of product `%s`. %s\n", version, p.Key(), err)
+				}
+				commit = &c
+			}
+			return *commit, true
+		}
+
+		return "", false
+	}
+}
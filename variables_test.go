@@ -0,0 +1,32 @@
+//go:build unit || ci
+
+package docweaver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSubstituteVariables(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		switch name {
+		case "var.host":
+			return "example.com", true
+		case "product":
+			return "Foo", true
+		}
+		return "", false
+	}
+
+	md := "# {{product}}\n\nHost: {{ var.host }}, `{{var.host}}`, {{var.missing}} {{var.missing}}, {{version}}.\n\n" +
+		"```sh\ncurl {{var.host}}\n```\n\n" +
+		"```sh vars=false\ncurl {{var.host}}\n```\n\n" +
+		"~~~~ vars=false\n~~~\n{{var.host}}\n~~~~\n{{var.host}}\n"
+	out, diagnostics := substituteVariables([]byte(md), lookup)
+
+	assert.Equal(t, "# Foo\n\nHost: example.com, `example.com`, {{var.missing}} {{var.missing}}, {{version}}.\n\n"+
+		"```sh\ncurl example.com\n```\n\n"+
+		"```sh vars=false\ncurl {{var.host}}\n```\n\n"+
+		"~~~~ vars=false\n~~~\n{{var.host}}\n~~~~\nexample.com\n", string(out))
+	assert.Equal(t, []Diagnostic{{Severity: SeverityWarning, Message: "Unknown variable `var.missing`."}}, diagnostics)
+}